@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"token_transfer/graph"
+	"token_transfer/grpcserver"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type apiKeyContextKey struct{}
+
+// API-key authentication of GraphQL operations
+// Mutations always require a key; queries and subscriptions only when publicQueries is false
+// In dev mode, introspection-only queries are allowed without a key for the playground
+// Admin keys are accepted wherever a key is, and are the only keys allowed to use adminFields
+type apiKeyAuth struct {
+	keys          []string
+	adminKeys     []string
+	publicQueries bool
+	production    bool
+}
+
+// Keys are a comma-separated list; authentication is disabled when it is empty
+func newAPIKeyAuth(keys string, publicQueries, production bool) *apiKeyAuth {
+	return &apiKeyAuth{keys: splitKeys(keys), publicQueries: publicQueries, production: production}
+}
+
+// Set the comma-separated admin keys
+func (a *apiKeyAuth) withAdminKeys(keys string) *apiKeyAuth {
+	a.adminKeys = splitKeys(keys)
+	return a
+}
+
+func splitKeys(keys string) []string {
+	var split []string
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			split = append(split, key)
+		}
+	}
+	return split
+}
+
+func (a *apiKeyAuth) enabled() bool {
+	return len(a.keys) > 0 || len(a.adminKeys) > 0
+}
+
+// Identity prefix of admin keys, e.g. "admin-key-1"
+const adminIdentityPrefix = "admin-key-"
+
+// Return identity of the key in "Bearer <key>" value, e.g. "api-key-2" for the second configured key
+// or "admin-key-1" for the first admin key
+// The key itself is never used as identity, so it does not end up in logs or the audit table
+func (a *apiKeyAuth) authenticate(authorization string) (string, bool) {
+	key, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || key == "" {
+		return "", false
+	}
+
+	identity := ""
+	for i, candidate := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			identity = fmt.Sprintf("api-key-%d", i+1)
+		}
+	}
+	for i, candidate := range a.adminKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			identity = fmt.Sprintf("%s%d", adminIdentityPrefix, i+1)
+		}
+	}
+	return identity, identity != ""
+}
+
+// Attach identity of the authenticated key to the request and to treasury audit entries
+func withAPIKey(ctx context.Context, identity string) context.Context {
+	ctx = context.WithValue(ctx, apiKeyContextKey{}, identity)
+	return graph.WithActor(ctx, identity)
+}
+
+func apiKeyFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return identity
+}
+
+// Read Authorization header; a request with an invalid key is rejected right away
+// Requests without the header continue, so that Operations can decide if a key is needed
+func (a *apiKeyAuth) middleware(next http.Handler) http.Handler {
+	if !a.enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, ok := a.authenticate(authorization)
+		if !ok {
+			http.Error(w, "invalid API key", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withAPIKey(r.Context(), identity)))
+	})
+}
+
+// Require a valid key on REST requests: always for writes, for reads unless queries are public
+func (a *apiKeyAuth) requireKey(write bool, next http.Handler) http.Handler {
+	if !a.enabled() || (!write && a.publicQueries) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := a.authenticate(r.Header.Get("Authorization"))
+		if !ok {
+			writeRESTError(w, http.StatusUnauthorized, "unauthorized", "valid API key required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withAPIKey(r.Context(), identity)))
+	})
+}
+
+// Require a valid key in "authorization" metadata of gRPC calls, with the same rules as REST
+func (a *apiKeyAuth) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !a.enabled() || (!grpcserver.IsWriteMethod(info.FullMethod) && a.publicQueries) {
+		return handler(ctx, req)
+	}
+
+	authorization := ""
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	identity, ok := a.authenticate(authorization)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "valid API key required")
+	}
+	return handler(withAPIKey(ctx, identity), req)
+}
+
+// Websocket clients cannot set headers, so the key is read from the connection_init payload
+func (a *apiKeyAuth) websocketInit(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	if !a.enabled() || apiKeyFromContext(ctx) != "" || initPayload.Authorization() == "" {
+		return ctx, nil, nil
+	}
+
+	identity, ok := a.authenticate(initPayload.Authorization())
+	if !ok {
+		return ctx, nil, fmt.Errorf("invalid API key")
+	}
+	return withAPIKey(ctx, identity), nil, nil
+}
+
+// Operation middleware rejecting operations that need a key when none was provided
+func (a *apiKeyAuth) operations(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if a.enabled() && apiKeyFromContext(ctx) == "" && a.requiresKey(graphql.GetOperationContext(ctx).Operation) {
+		return graphql.OneShot(graphql.ErrorResponse(ctx, "unauthorized: valid API key required"))
+	}
+	return next(ctx)
+}
+
+// Root fields that create or destroy tokens, spend the treasury, block wallets or read or
+// replace the whole ledger
+var adminFields = map[string]bool{
+	"Mutation.mint":             true,
+	"Mutation.burn":             true,
+	"Mutation.freezeWallet":     true,
+	"Mutation.unfreezeWallet":   true,
+	"Mutation.importLedger":     true,
+	"Mutation.treasuryTransfer": true,
+	"Query.exportLedger":        true,
+	"Query.negativeBalances":    true,
+}
+
+// Field middleware rejecting adminFields unless the request was made with an admin key
+// Checked on every resolved field, so aliases and fragments do not get around it
+func (a *apiKeyAuth) fields(ctx context.Context, next graphql.Resolver) (any, error) {
+	field := graphql.GetFieldContext(ctx)
+	if a.enabled() && adminFields[field.Object+"."+field.Field.Name] &&
+		!strings.HasPrefix(apiKeyFromContext(ctx), adminIdentityPrefix) {
+		return nil, fmt.Errorf("forbidden: admin API key required")
+	}
+	return next(ctx)
+}
+
+func (a *apiKeyAuth) requiresKey(operation *ast.OperationDefinition) bool {
+	if operation == nil || operation.Operation == ast.Mutation {
+		return true
+	}
+	if a.publicQueries {
+		return false
+	}
+	return a.production || !isIntrospection(operation)
+}
+
+// Check if operation selects only introspection fields such as __schema and __type
+func isIntrospection(operation *ast.OperationDefinition) bool {
+	if operation.Operation != ast.Query || len(operation.SelectionSet) == 0 {
+		return false
+	}
+	for _, selection := range operation.SelectionSet {
+		field, ok := selection.(*ast.Field)
+		if !ok || !strings.HasPrefix(field.Name, "__") {
+			return false
+		}
+	}
+	return true
+}
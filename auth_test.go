@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/grpcserver/transferpb"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	auth := newAPIKeyAuth("first, second", false, false).withAdminKeys("root")
+
+	cases := []struct {
+		authorization string
+		identity      string
+		ok            bool
+	}{
+		{"Bearer first", "api-key-1", true},
+		{"Bearer second", "api-key-2", true},
+		{"Bearer root", "admin-key-1", true},
+		{"Bearer third", "", false},
+		{"second", "", false},
+		{"Bearer ", "", false},
+	}
+
+	for _, c := range cases {
+		identity, ok := auth.authenticate(c.authorization)
+		if identity != c.identity || ok != c.ok {
+			t.Errorf("%q: expected (%q, %v), got (%q, %v)", c.authorization, c.identity, c.ok, identity, ok)
+		}
+	}
+}
+
+func TestAPIKeyAuthOperations(t *testing.T) {
+	// Server without DB: only operations rejected before resolvers run are executed
+	newServer := func(auth *apiKeyAuth) http.Handler {
+		srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+		srv.AddTransport(transport.POST{})
+		srv.AroundOperations(auth.operations)
+		srv.AroundFields(auth.fields)
+		return auth.middleware(srv)
+	}
+
+	post := func(h http.Handler, query, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	mutation := `mutation { transfer(from_address: \"0xa000000000000000000000000000000000000000\", to_address: \"0xb000000000000000000000000000000000000000\", amount: \"1\") { sender_balance } }`
+	introspection := `{ __typename }`
+
+	cases := []struct {
+		name          string
+		auth          *apiKeyAuth
+		query         string
+		authorization string
+		status        int
+		rejected      bool
+	}{
+		{"mutation without key", newAPIKeyAuth("secret", true, false), mutation, "", http.StatusOK, true},
+		{"mutation with invalid key", newAPIKeyAuth("secret", true, false), mutation, "Bearer wrong", http.StatusUnauthorized, false},
+		{"introspection in dev", newAPIKeyAuth("secret", false, false), introspection, "", http.StatusOK, false},
+		{"introspection in production", newAPIKeyAuth("secret", false, true), introspection, "", http.StatusOK, true},
+		{"public introspection in production", newAPIKeyAuth("secret", true, true), introspection, "", http.StatusOK, false},
+		{"introspection with key", newAPIKeyAuth("secret", false, true), introspection, "Bearer secret", http.StatusOK, false},
+		{"auth disabled", newAPIKeyAuth("", false, true), introspection, "", http.StatusOK, false},
+	}
+
+	for _, c := range cases {
+		rec := post(newServer(c.auth), c.query, c.authorization)
+		if rec.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rec.Code)
+			continue
+		}
+		if rejected := strings.Contains(rec.Body.String(), "valid API key required"); rejected != c.rejected {
+			t.Errorf("%s: expected rejected=%v, got body %s", c.name, c.rejected, rec.Body.String())
+		}
+	}
+
+	// Admin fields need an admin key, also behind an alias or a fragment; the resolvers then
+	// fail before any DB call, as minting and ledger export are disabled
+	auth := newAPIKeyAuth("secret", false, false).withAdminKeys("root")
+	mint := `mutation { mint(to_address: \"0xa000000000000000000000000000000000000000\", amount: \"1\") }`
+	aliased := `mutation { grant: mint(to_address: \"0xa000000000000000000000000000000000000000\", amount: \"1\") }`
+	export := `query { ...backup } fragment backup on Query { exportLedger }`
+	negative := `query { negativeBalances { address } }`
+	selfTransfer := `mutation { transfer(from_address: \"0xa000000000000000000000000000000000000000\", to_address: \"0xa000000000000000000000000000000000000000\", amount: \"1\") { sender_balance } }`
+	adminCases := []struct {
+		name          string
+		query         string
+		authorization string
+		forbidden     bool
+	}{
+		{"mint with key", mint, "Bearer secret", true},
+		{"aliased mint with key", aliased, "Bearer secret", true},
+		{"export in fragment with key", export, "Bearer secret", true},
+		{"negative balances with key", negative, "Bearer secret", true},
+		{"mint with admin key", mint, "Bearer root", false},
+		{"export with admin key", export, "Bearer root", false},
+		{"transfer with admin key", selfTransfer, "Bearer root", false},
+	}
+	for _, c := range adminCases {
+		body := post(newServer(auth), c.query, c.authorization).Body.String()
+		if forbidden := strings.Contains(body, "admin API key required"); forbidden != c.forbidden {
+			t.Errorf("%s: expected forbidden=%v, got body %s", c.name, c.forbidden, body)
+		}
+		if strings.Contains(body, "valid API key required") {
+			t.Errorf("%s: expected key to be accepted, got body %s", c.name, body)
+		}
+	}
+}
+
+func TestAPIKeyUnaryInterceptor(t *testing.T) {
+	var identity string
+	handler := func(ctx context.Context, req any) (any, error) {
+		identity = apiKeyFromContext(ctx)
+		return nil, nil
+	}
+	call := func(auth *apiKeyAuth, method, authorization string) error {
+		identity = ""
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+		}
+		_, err := auth.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	transfer, getWallet := transferpb.TokenTransfer_Transfer_FullMethodName, transferpb.TokenTransfer_GetWallet_FullMethodName
+	cases := []struct {
+		auth          *apiKeyAuth
+		method        string
+		authorization string
+		code          codes.Code
+	}{
+		{newAPIKeyAuth("", false, false), transfer, "", codes.OK},
+		{newAPIKeyAuth("secret", false, false), transfer, "", codes.Unauthenticated},
+		{newAPIKeyAuth("secret", false, false), transfer, "Bearer wrong", codes.Unauthenticated},
+		{newAPIKeyAuth("secret", false, false), transfer, "Bearer secret", codes.OK},
+		{newAPIKeyAuth("secret", false, false), getWallet, "", codes.Unauthenticated},
+		{newAPIKeyAuth("secret", true, false), getWallet, "", codes.OK},
+		{newAPIKeyAuth("secret", true, false), transfer, "", codes.Unauthenticated},
+	}
+
+	for _, c := range cases {
+		if code := status.Code(call(c.auth, c.method, c.authorization)); code != c.code {
+			t.Errorf("%s with %q: expected %s, got %s", c.method, c.authorization, c.code, code)
+		}
+	}
+
+	// Identity of the key is attached to the context
+	if err := call(newAPIKeyAuth("secret", false, false), transfer, "Bearer secret"); err != nil || identity != "api-key-1" {
+		t.Errorf("Expected api-key-1 identity, got %q (%v)", identity, err)
+	}
+}
+
+func TestGRPCClientIP(t *testing.T) {
+	var seen string
+	handler := func(ctx context.Context, req any) (any, error) {
+		seen = graph.ClientIPFromContext(ctx)
+		return nil, nil
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}})
+	if _, err := grpcClientIP(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil || seen != "203.0.113.7" {
+		t.Errorf("Expected client IP 203.0.113.7, got %q, %v", seen, err)
+	}
+
+	// Calls without a peer, e.g. in-process, carry no client IP
+	if _, err := grpcClientIP(context.Background(), nil, &grpc.UnaryServerInfo{}, handler); err != nil || seen != "" {
+		t.Errorf("Expected no client IP without a peer, got %q, %v", seen, err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"token_transfer/graph"
+	"token_transfer/graph/model"
+
+	"github.com/shopspring/decimal"
+)
+
+const commandUsage = `commands:
+  init                                              run migrations and create treasury wallet with INITIAL_SUPPLY
+  transfer --from ADDRESS --to ADDRESS --amount N   transfer tokens and print the result as JSON
+  balance --address ADDRESS                         print wallet balance`
+
+// Run one-off subcommand given in args, e.g. ["balance", "--address", "0x..."], instead of the server
+// Results go to stdout, so they can be used in scripts
+func runCommand(ctx context.Context, resolver *graph.Resolver, args []string, stdout io.Writer) error {
+	switch args[0] {
+	case "init":
+		return runInit(ctx, resolver, args[1:], stdout)
+	case "transfer":
+		return runTransfer(ctx, resolver, args[1:], stdout)
+	case "balance":
+		return runBalance(ctx, resolver, args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], commandUsage)
+	}
+}
+
+// Parse flags of a subcommand; every declared flag is required and no positional arguments are accepted
+func parseCommandFlags(name string, args []string, flags map[string]*string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	for flagName, value := range flags {
+		fs.StringVar(value, flagName, "", flagName)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected argument %q", name, fs.Arg(0))
+	}
+	for flagName, value := range flags {
+		if *value == "" {
+			return fmt.Errorf("%s: missing --%s", name, flagName)
+		}
+	}
+	return nil
+}
+
+// One-time setup: create treasury wallet with initial supply
+func runInit(ctx context.Context, resolver *graph.Resolver, args []string, stdout io.Writer) error {
+	if err := parseCommandFlags("init", args, nil); err != nil {
+		return err
+	}
+
+	initialSupply, err := decimal.NewFromString(getEnv("INITIAL_SUPPLY", "1000000"))
+	if err != nil {
+		return fmt.Errorf("invalid INITIAL_SUPPLY: %w", err)
+	}
+
+	// Tables are created first, so init works on a DB without any schema; already applied
+	// migrations, e.g. with RUN_MIGRATIONS=true, are skipped
+	if err := runMigrations(resolver.DB); err != nil {
+		return fmt.Errorf("migrations failed: %w", err)
+	}
+	if err := resolver.Initialize(ctx, initialSupply); err != nil {
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+	fmt.Fprintf(stdout, "Initialized treasury %s with %s tokens.\n", resolver.TreasuryAddress, initialSupply)
+	return nil
+}
+
+// Transfer with the same validation, limits and locking as the transfer mutation
+func runTransfer(ctx context.Context, resolver *graph.Resolver, args []string, stdout io.Writer) error {
+	var from, to, amount string
+	if err := parseCommandFlags("transfer", args, map[string]*string{"from": &from, "to": &to, "amount": &amount}); err != nil {
+		return err
+	}
+
+	result, err := resolver.Service().Transfer(ctx, from, to, amount)
+	if err != nil {
+		return fmt.Errorf("transfer failed: %w", err)
+	}
+	return json.NewEncoder(stdout).Encode(result)
+}
+
+// Print balance of a single wallet
+func runBalance(ctx context.Context, resolver *graph.Resolver, args []string, stdout io.Writer) error {
+	var address string
+	if err := parseCommandFlags("balance", args, map[string]*string{"address": &address}); err != nil {
+		return err
+	}
+	if !model.ValidAddress(address) {
+		return fmt.Errorf("invalid address %q", address)
+	}
+
+	wallet, err := resolver.Query().Wallet(ctx, address, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, wallet.Balance)
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+)
+
+func TestRunCommandRejectedBeforeDB(t *testing.T) {
+	// No DB: only commands failing before any query are run
+	resolver := &graph.Resolver{WalletTable: "wallets"}
+
+	cases := []struct {
+		args    []string
+		message string
+	}{
+		{[]string{"serve"}, `unknown command "serve"`},
+		{[]string{"balance"}, "balance: missing --address"},
+		{[]string{"balance", "--address", "0x123"}, `invalid address "0x123"`},
+		{[]string{"balance", "--address", "0xa000000000000000000000000000000000000000", "extra"}, `balance: unexpected argument "extra"`},
+		{[]string{"balance", "--wallet", "0xa000000000000000000000000000000000000000"}, "flag provided but not defined: -wallet"},
+		{[]string{"transfer", "--from", "0xa000000000000000000000000000000000000000", "--amount", "1"}, "transfer: missing --to"},
+		{[]string{"init", "now"}, `init: unexpected argument "now"`},
+	}
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		err := runCommand(context.Background(), resolver, c.args, &stdout)
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%v: expected error containing %q, got: %v", c.args, c.message, err)
+		}
+		if stdout.Len() > 0 {
+			t.Errorf("%v: expected no output, got %q", c.args, stdout.String())
+		}
+	}
+
+	// Transfer input is validated by the service, as for the mutation
+	args := []string{"transfer", "--from", "0xa000000000000000000000000000000000000000", "--to", "0xb000000000000000000000000000000000000000", "--amount", "-1"}
+	if err := runCommand(context.Background(), resolver, args, &bytes.Buffer{}); !errors.Is(err, graph.ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection pool defaults
+// 25 open connections stay well below the Postgres default of max_connections=100,
+// leaving room for other clients; connections are recycled every 30 minutes
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 30 * time.Minute
+)
+
+// HTTP server timeout defaults, so slow clients cannot hold connections open
+const (
+	defaultHTTPReadTimeout  = 10 * time.Second
+	defaultHTTPWriteTimeout = 30 * time.Second
+	defaultHTTPIdleTimeout  = 120 * time.Second
+)
+
+// Values of TRANSFER_ISOLATION
+var isolationLevels = map[string]sql.IsolationLevel{
+	"read_committed":  sql.LevelReadCommitted,
+	"repeatable_read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+// DB connection settings read from DB_* environment variables
+type dbConfig struct {
+	User     string
+	Password string
+	Name     string
+	Host     string
+	Port     string
+
+	MaxOpenConns    int
+	MaxIdleConns    int // never more than MaxOpenConns
+	ConnMaxLifetime time.Duration
+
+	// Behind PgBouncer in transaction pooling mode; set by POOLER_COMPATIBLE=true
+	PoolerCompatible bool
+}
+
+// Read DB_* variables; all of them are required
+// Error lists every missing variable, so they can be fixed in one go
+func loadConfig() (dbConfig, error) {
+	var (
+		config  dbConfig
+		missing []string
+	)
+
+	for _, v := range []struct {
+		key   string
+		value *string
+	}{
+		{"DB_USER", &config.User},
+		{"DB_PASSWORD", &config.Password},
+		{"DB_NAME", &config.Name},
+		{"DB_HOST", &config.Host},
+		{"DB_PORT", &config.Port},
+	} {
+		*v.value = os.Getenv(v.key)
+		if *v.value == "" {
+			missing = append(missing, v.key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return dbConfig{}, fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+
+	// Optional pool settings
+	config.MaxOpenConns = defaultMaxOpenConns
+	config.MaxIdleConns = defaultMaxIdleConns
+
+	if value := os.Getenv("DB_MAX_OPEN_CONNS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return dbConfig{}, fmt.Errorf("invalid DB_MAX_OPEN_CONNS %q", value)
+		}
+		config.MaxOpenConns = n
+	}
+	if value := os.Getenv("DB_MAX_IDLE_CONNS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return dbConfig{}, fmt.Errorf("invalid DB_MAX_IDLE_CONNS %q", value)
+		}
+		config.MaxIdleConns = n
+	}
+	lifetime, err := durationEnv("DB_CONN_MAX_LIFETIME", defaultConnMaxLifetime, false)
+	if err != nil {
+		return dbConfig{}, err
+	}
+	config.ConnMaxLifetime = lifetime
+
+	config.PoolerCompatible = os.Getenv("POOLER_COMPATIBLE") == "true"
+
+	// Idle connections above the open limit would be closed anyway
+	config.MaxIdleConns = min(config.MaxIdleConns, config.MaxOpenConns)
+
+	return config, nil
+}
+
+// Read replica settings from DB_REPLICA_* variables; ok is false when DB_REPLICA_HOST is not set
+// Other settings default to the primary's, including the pool
+func (c dbConfig) replicaConfig() (replica dbConfig, ok bool) {
+	replica = c
+	for _, v := range []struct {
+		key   string
+		value *string
+	}{
+		{"DB_REPLICA_USER", &replica.User},
+		{"DB_REPLICA_PASSWORD", &replica.Password},
+		{"DB_REPLICA_NAME", &replica.Name},
+		{"DB_REPLICA_HOST", &replica.Host},
+		{"DB_REPLICA_PORT", &replica.Port},
+	} {
+		if value := os.Getenv(v.key); value != "" {
+			*v.value = value
+		}
+	}
+	return replica, os.Getenv("DB_REPLICA_HOST") != ""
+}
+
+// Apply pool settings to DB handle
+func (c dbConfig) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+}
+
+// Connection string for lib/pq
+// Behind a pooler, parameters are sent with the query in one round trip, so lib/pq never
+// relies on an unnamed statement surviving until the next message, which may reach another server
+func (c dbConfig) connString() string {
+	conn := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
+		c.User, c.Password, c.Name, c.Host, c.Port)
+	if c.PoolerCompatible {
+		conn += " binary_parameters=yes"
+	}
+	return conn
+}
+
+// Read a duration such as "500ms" from environment variable name; fallback when it is not set
+// Negative values are rejected, and so is 0 unless allowZero is set
+func durationEnv(name string, fallback time.Duration, allowZero bool) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 || (d == 0 && !allowZero) {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
+
+// HTTP server timeouts read from HTTP_*_TIMEOUT variables; 0 disables one
+type httpTimeouts struct {
+	Read  time.Duration // also used for the request headers
+	Write time.Duration
+	Idle  time.Duration
+}
+
+// Read HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT
+func loadHTTPTimeouts() (httpTimeouts, error) {
+	timeouts := httpTimeouts{
+		Read:  defaultHTTPReadTimeout,
+		Write: defaultHTTPWriteTimeout,
+		Idle:  defaultHTTPIdleTimeout,
+	}
+	for _, v := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", &timeouts.Read},
+		{"HTTP_WRITE_TIMEOUT", &timeouts.Write},
+		{"HTTP_IDLE_TIMEOUT", &timeouts.Idle},
+	} {
+		d, err := durationEnv(v.key, *v.value, true)
+		if err != nil {
+			return httpTimeouts{}, err
+		}
+		*v.value = d
+	}
+	return timeouts, nil
+}
+
+// HTTP server for handler on addr with these timeouts
+func (t httpTimeouts) server(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: t.Read,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
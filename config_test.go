@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "WalletDB")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_PORT", "5432")
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	expected := "user=postgres password=secret dbname=WalletDB host=db port=5432 sslmode=disable"
+	if config.connString() != expected {
+		t.Errorf("Expected connection string %q, got %q", expected, config.connString())
+	}
+
+	// Parameters go with the query behind a pooler
+	t.Setenv("POOLER_COMPATIBLE", "true")
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if config.connString() != expected+" binary_parameters=yes" {
+		t.Errorf("Expected binary_parameters in connection string, got %q", config.connString())
+	}
+
+	// Every missing variable is listed
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PORT", "")
+
+	_, err = loadConfig()
+	if err == nil {
+		t.Fatal("Missing variables did not throw error")
+	}
+	for _, key := range []string{"DB_USER", "DB_PASSWORD", "DB_PORT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention %s, got: %v", key, err)
+		}
+	}
+	for _, key := range []string{"DB_NAME", "DB_HOST"} {
+		if strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error not to mention %s, got: %v", key, err)
+		}
+	}
+}
+
+func TestLoadConfigPool(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "WalletDB")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_PORT", "5432")
+
+	// Defaults
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if config.MaxOpenConns != 25 || config.MaxIdleConns != 10 || config.ConnMaxLifetime != 30*time.Minute {
+		t.Errorf("Unexpected default pool settings: %+v", config)
+	}
+
+	// Idle connections are capped by open connections
+	t.Setenv("DB_MAX_OPEN_CONNS", "5")
+	t.Setenv("DB_MAX_IDLE_CONNS", "20")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1m")
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if config.MaxOpenConns != 5 || config.MaxIdleConns != 5 || config.ConnMaxLifetime != time.Minute {
+		t.Errorf("Unexpected pool settings: %+v", config)
+	}
+
+	// Invalid values
+	for key, value := range map[string]string{
+		"DB_MAX_OPEN_CONNS":    "0",
+		"DB_MAX_IDLE_CONNS":    "-1",
+		"DB_CONN_MAX_LIFETIME": "forever",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), key) {
+				t.Errorf("Expected error mentioning %s, got: %v", key, err)
+			}
+		})
+	}
+}
+
+func TestReplicaConfig(t *testing.T) {
+	primary := dbConfig{User: "postgres", Password: "secret", Name: "WalletDB", Host: "db", Port: "5432", MaxOpenConns: 5}
+
+	// Not configured without DB_REPLICA_HOST
+	t.Setenv("DB_REPLICA_PORT", "5433")
+	if _, ok := primary.replicaConfig(); ok {
+		t.Error("Expected no replica without DB_REPLICA_HOST")
+	}
+
+	// Unset settings come from the primary
+	t.Setenv("DB_REPLICA_HOST", "replica")
+	t.Setenv("DB_REPLICA_USER", "reader")
+	replica, ok := primary.replicaConfig()
+	expected := dbConfig{User: "reader", Password: "secret", Name: "WalletDB", Host: "replica", Port: "5433", MaxOpenConns: 5}
+	if !ok || replica != expected {
+		t.Errorf("Expected %+v, got %+v (ok=%v)", expected, replica, ok)
+	}
+}
+
+func TestDurationEnv(t *testing.T) {
+	// Unset variable keeps the fallback
+	d, err := durationEnv("TEST_DURATION", 5*time.Second, false)
+	if err != nil || d != 5*time.Second {
+		t.Errorf("Expected fallback 5s, got %s, %v", d, err)
+	}
+
+	t.Setenv("TEST_DURATION", "250ms")
+	d, err = durationEnv("TEST_DURATION", 5*time.Second, false)
+	if err != nil || d != 250*time.Millisecond {
+		t.Errorf("Expected 250ms, got %s, %v", d, err)
+	}
+
+	// 0 only when allowed
+	t.Setenv("TEST_DURATION", "0")
+	if d, err := durationEnv("TEST_DURATION", time.Second, true); err != nil || d != 0 {
+		t.Errorf("Expected 0, got %s, %v", d, err)
+	}
+	if _, err := durationEnv("TEST_DURATION", time.Second, false); err == nil {
+		t.Error("Expected error for 0 when not allowed")
+	}
+
+	for _, value := range []string{"-1s", "5", "soon"} {
+		t.Setenv("TEST_DURATION", value)
+		_, err := durationEnv("TEST_DURATION", time.Second, true)
+		if err == nil || err.Error() != `invalid TEST_DURATION "`+value+`"` {
+			t.Errorf("Expected invalid TEST_DURATION error for %q, got: %v", value, err)
+		}
+	}
+}
+
+func TestLoadHTTPTimeouts(t *testing.T) {
+	// Defaults
+	timeouts, err := loadHTTPTimeouts()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if timeouts != (httpTimeouts{Read: 10 * time.Second, Write: 30 * time.Second, Idle: 120 * time.Second}) {
+		t.Errorf("Unexpected default timeouts: %+v", timeouts)
+	}
+
+	// Overrides reach the server; 0 disables a timeout
+	t.Setenv("HTTP_READ_TIMEOUT", "2s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "0")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "1m")
+	timeouts, err = loadHTTPTimeouts()
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	server := timeouts.server(":8080", http.NotFoundHandler())
+	if server.Addr != ":8080" || server.ReadHeaderTimeout != 2*time.Second || server.ReadTimeout != 2*time.Second ||
+		server.WriteTimeout != 0 || server.IdleTimeout != time.Minute {
+		t.Errorf("Unexpected server timeouts: %+v", server)
+	}
+
+	// Invalid values
+	for _, key := range []string{"HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT"} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, "-1s")
+			if _, err := loadHTTPTimeouts(); err == nil || !strings.Contains(err.Error(), key) {
+				t.Errorf("Expected error mentioning %s, got: %v", key, err)
+			}
+		})
+	}
+}
@@ -1,11 +1,91 @@
 CREATE TABLE wallets (
-    address TEXT PRIMARY KEY,
-    token_balance NUMERIC(28,18) NOT NULL CHECK (token_balance >= 0)
+    address TEXT NOT NULL,
+    asset TEXT NOT NULL DEFAULT 'TOKEN',
+    token_balance NUMERIC(28,18) NOT NULL CHECK (token_balance >= 0),
+    owner_id TEXT,
+    frozen BOOLEAN NOT NULL DEFAULT FALSE,
+    version BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (address, asset)
 );
 
+CREATE INDEX wallets_owner_id_idx ON wallets (owner_id);
+
 CREATE TABLE test_wallets (
-    address TEXT PRIMARY KEY,
-    token_balance NUMERIC(28,18) NOT NULL CHECK (token_balance >= 0)
+    address TEXT NOT NULL,
+    asset TEXT NOT NULL DEFAULT 'TOKEN',
+    token_balance NUMERIC(28,18) NOT NULL CHECK (token_balance >= 0),
+    owner_id TEXT,
+    frozen BOOLEAN NOT NULL DEFAULT FALSE,
+    version BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (address, asset)
+);
+
+CREATE INDEX test_wallets_owner_id_idx ON test_wallets (owner_id);
+
+CREATE TABLE transactions (
+    id BIGSERIAL PRIMARY KEY,
+    from_address TEXT NOT NULL,
+    to_address TEXT NOT NULL,
+    asset TEXT NOT NULL DEFAULT 'TOKEN',
+    amount NUMERIC(28,18) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE,
+    memo TEXT
+);
+
+CREATE TABLE test_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    from_address TEXT NOT NULL,
+    to_address TEXT NOT NULL,
+    asset TEXT NOT NULL DEFAULT 'TOKEN',
+    amount NUMERIC(28,18) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE,
+    memo TEXT
+);
+
+CREATE TABLE transactions_head (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    hash TEXT NOT NULL
+);
+
+CREATE TABLE test_transactions_head (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    hash TEXT NOT NULL
+);
+
+CREATE TABLE treasury_audit (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT,
+    to_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE test_treasury_audit (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT,
+    to_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE allowances (
+    owner_address TEXT NOT NULL,
+    spender_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (owner_address, spender_address)
+);
+
+CREATE TABLE test_allowances (
+    owner_address TEXT NOT NULL,
+    spender_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (owner_address, spender_address)
 );
 
 INSERT INTO wallets (address, token_balance)
@@ -13,3 +93,17 @@ VALUES ('0x0000000000000000000000000000000000000000', 1000000);
 
 INSERT INTO test_wallets (address, token_balance)
 VALUES ('0x0000000000000000000000000000000000000000', 1000000);
+
+-- Initial supply is logged as coming from "supply", so the treasury reconciles against the log
+INSERT INTO transactions (id, from_address, to_address, amount, created_at, prev_hash, hash)
+VALUES (1, 'supply', '0x0000000000000000000000000000000000000000', 1000000, '2025-01-01T00:00:00Z',
+        '0000000000000000000000000000000000000000000000000000000000000000',
+        '4c1aca895bef71b13e16905ef0161b2181d8c4d6a8f19b13a949165615d19c4f');
+
+SELECT setval(pg_get_serial_sequence('transactions', 'id'), 1);
+
+INSERT INTO transactions_head (hash)
+VALUES ('4c1aca895bef71b13e16905ef0161b2181d8c4d6a8f19b13a949165615d19c4f');
+
+INSERT INTO test_transactions_head (hash)
+VALUES ('0000000000000000000000000000000000000000000000000000000000000000');
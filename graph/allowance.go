@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Allowance amount: a valid token amount, or zero to revoke
+func validateAllowanceAmount(amount string) error {
+	err := validateTokenAmount(amount)
+	var validationErr *validationError
+	if errors.As(err, &validationErr) && validationErr.reason == "non_positive_amount" && decimal.RequireFromString(amount).IsZero() {
+		return nil
+	}
+	return err
+}
+
+// Set allowance of spender on owner's wallet, replacing any previous one; returns the stored amount
+func (r *Resolver) setAllowance(ctx context.Context, owner, spender, amount string) (string, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (owner_address, spender_address, amount) VALUES ($1, $2, $3::numeric)
+		ON CONFLICT (owner_address, spender_address) DO UPDATE SET amount = EXCLUDED.amount
+		RETURNING amount`, r.AllowanceTable)
+	var stored string
+	err := r.DB.QueryRowContext(ctx, query, owner, spender, amount).Scan(&stored)
+	return stored, err
+}
+
+// Decrease allowance of spender on owner's wallet by amount, failing with ErrInsufficientAllowance when it is too small
+// The conditional update locks the allowance row until commit, so concurrent transferFrom calls cannot overspend it
+func (r *Resolver) spendAllowance(ctx context.Context, tx *sql.Tx, owner, spender, amount string) error {
+	query := fmt.Sprintf(`UPDATE %s SET amount = amount - $3::numeric
+		WHERE owner_address = $1 AND spender_address = $2 AND amount >= $3::numeric`, r.AllowanceTable)
+	result, err := tx.ExecContext(ctx, query, owner, spender, amount)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: %s on %s", ErrInsufficientAllowance, spender, owner)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateAllowanceAmount(t *testing.T) {
+	for _, amount := range []string{"0", "0.0", "1", "0.000000000000000001"} {
+		if err := validateAllowanceAmount(amount); err != nil {
+			t.Errorf("%s: unexpected error %v", amount, err)
+		}
+	}
+	for _, amount := range []string{"-1", "abc", "1e3", "0.0000000000000000001"} {
+		if err := validateAllowanceAmount(amount); err == nil {
+			t.Errorf("%s: expected error", amount)
+		}
+	}
+}
+
+func TestAllowancesDisabled(t *testing.T) {
+	r := &Resolver{WalletTable: "wallets"}
+	ctx := context.Background()
+	owner := "0xa000000000000000000000000000000000000000"
+	spender := "0xb000000000000000000000000000000000000000"
+
+	if _, err := r.Mutation().Approve(ctx, owner, spender, "1"); err == nil {
+		t.Error("Expected approve to fail without allowance table")
+	}
+	if _, err := r.Mutation().TransferFrom(ctx, spender, owner, "0xc000000000000000000000000000000000000000", "1"); err == nil {
+		t.Error("Expected transferFrom to fail without allowance table")
+	}
+	if _, err := r.Query().Allowance(ctx, owner, spender); err == nil {
+		t.Error("Expected allowance query to fail without allowance table")
+	}
+}
+
+func TestAllowanceQueryRejectsInvalidAddress(t *testing.T) {
+	// No DB: invalid addresses must fail before any query
+	r := &Resolver{WalletTable: "wallets", AllowanceTable: "allowances"}
+	ctx := context.Background()
+	valid := "0xa000000000000000000000000000000000000000"
+
+	if _, err := r.Query().Allowance(ctx, "0x123", valid); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for owner, got %v", err)
+	}
+	if _, err := r.Query().Allowance(ctx, valid, "not-an-address"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress for spender, got %v", err)
+	}
+}
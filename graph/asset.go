@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Asset of wallets created before assets existed, and of every operation without an asset argument
+const defaultBaseAsset = "TOKEN"
+
+// Asset codes are short upper-case tickers, e.g. "TOKEN" or "USDC"
+var assetRegex = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,15}$`)
+
+func validateAsset(asset string) error {
+	if !assetRegex.MatchString(asset) {
+		return &validationError{"invalid_asset", "invalid asset: must be 1-16 upper-case letters or digits, starting with a letter"}
+	}
+	return nil
+}
+
+// Return configured base asset or the default one
+func (r *Resolver) baseAsset() string {
+	if r.BaseAsset != "" {
+		return r.BaseAsset
+	}
+	return defaultBaseAsset
+}
+
+// Asset argument of a request as given; nil or empty means the base asset
+func (r *Resolver) assetOrBase(asset *string) string {
+	if asset == nil || *asset == "" {
+		return r.baseAsset()
+	}
+	return *asset
+}
+
+// Validated asset argument of a request
+func (r *Resolver) requestAsset(ctx context.Context, asset *string) (string, error) {
+	code := r.assetOrBase(asset)
+	if err := validateAsset(code); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", err
+	}
+	return code, nil
+}
+
+// Name hashed into the advisory lock key of a wallet
+// Base asset wallets keep the plain address, so their keys are the same as before assets existed
+func (r *Resolver) lockName(address, asset string) string {
+	if asset == r.baseAsset() {
+		return address
+	}
+	return fmt.Sprintf("%s/%s", address, asset)
+}
+
+// Asset as part of a receipt hash; empty for the base asset, so existing chains still verify
+func (r *Resolver) hashAsset(asset string) string {
+	if asset == r.baseAsset() {
+		return ""
+	}
+	return asset
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestValidateAsset(t *testing.T) {
+	for _, asset := range []string{"TOKEN", "USDC", "A", "B2", "ABCDEFGHIJKLMNOP"} {
+		if err := validateAsset(asset); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", asset, err)
+		}
+	}
+	for _, asset := range []string{"", "usdc", "2B", "US-DC", " USDC", "ABCDEFGHIJKLMNOPQ"} {
+		if err := validateAsset(asset); err == nil {
+			t.Errorf("Expected %q to be rejected", asset)
+		}
+	}
+}
+
+func TestRequestAssetDefaultsToBase(t *testing.T) {
+	empty, usdc, invalid := "", "USDC", "usdc"
+
+	r := &Resolver{}
+	if asset, err := r.requestAsset(context.Background(), nil); err != nil || asset != "TOKEN" {
+		t.Errorf("Expected TOKEN without base asset configured, got %q, %v", asset, err)
+	}
+
+	r = &Resolver{BaseAsset: "GOLD"}
+	if asset, err := r.requestAsset(context.Background(), nil); err != nil || asset != "GOLD" {
+		t.Errorf("Expected configured base asset, got %q, %v", asset, err)
+	}
+	if asset, err := r.requestAsset(context.Background(), &empty); err != nil || asset != "GOLD" {
+		t.Errorf("Expected empty asset to mean base asset, got %q, %v", asset, err)
+	}
+	if asset, err := r.requestAsset(context.Background(), &usdc); err != nil || asset != "USDC" {
+		t.Errorf("Expected USDC, got %q, %v", asset, err)
+	}
+	if _, err := r.requestAsset(context.Background(), &invalid); err == nil || ErrorCategory(err) != "invalid_input" {
+		t.Errorf("Expected invalid_input error, got %v", err)
+	}
+}
+
+func TestBaseAssetKeepsLockNamesAndHashes(t *testing.T) {
+	r := &Resolver{}
+	address := "0xa000000000000000000000000000000000000000"
+
+	// Base asset wallets lock and hash as before assets existed
+	if name := r.lockName(address, "TOKEN"); name != address {
+		t.Errorf("Expected plain address as base asset lock name, got %s", name)
+	}
+	if name := r.lockName(address, "USDC"); name == address {
+		t.Error("Expected other assets to lock on a different name")
+	}
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	hash := func(asset string) string {
+		return receiptHash(1, address, "0xb000000000000000000000000000000000000000", "1.000000000000000000", createdAt, "", r.hashAsset(asset))
+	}
+	if hash("TOKEN") != receiptHash(1, address, "0xb000000000000000000000000000000000000000", "1.000000000000000000", createdAt, "", "") {
+		t.Error("Expected base asset receipt hash to stay unchanged")
+	}
+	if hash("USDC") == hash("TOKEN") {
+		t.Error("Expected asset to be part of the receipt hash")
+	}
+}
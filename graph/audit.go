@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"context"
+
+	"token_transfer/graph/model"
+)
+
+type requestIDContextKey struct{}
+
+type clientIPContextKey struct{}
+
+// Attach correlation ID of the request, included in audit lines and GraphQL errors
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// Correlation ID of the request, empty when none was attached
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// Attach address of the client, which attributes requests made without an API key
+func WithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey{}, clientIP)
+}
+
+// Address of the client, empty when none was attached
+func ClientIPFromContext(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPContextKey{}).(string)
+	return clientIP
+}
+
+// Who a request came from, for attributing rejected attempts: the API key identity when
+// authenticated, the client IP otherwise, and "unknown" for callers that attached neither
+func sourceFromContext(ctx context.Context) string {
+	if actor := actorFromContext(ctx); actor != "" {
+		return actor
+	}
+	if clientIP := ClientIPFromContext(ctx); clientIP != "" {
+		return clientIP
+	}
+	return "unknown"
+}
+
+// Source as a metric label: the API key identity, or "anonymous" for unauthenticated callers,
+// whose client IP only goes to log lines
+func metricSourceFromContext(ctx context.Context) string {
+	if actor := actorFromContext(ctx); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// Write audit log line for a transfer, also when history is disabled
+// Failed transfers are logged with their error category as reason and the source of the request
+// Lines carry request_id when the request has one
+func (r *Resolver) auditTransfer(ctx context.Context, fromAddress, toAddress, asset, amount string, transfer *model.TransferResult, err error) {
+	logger := r.auditLogger()
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+
+	if err != nil {
+		logger.Warn("transfer failed",
+			"from", fromAddress,
+			"to", toAddress,
+			"asset", asset,
+			"amount", amount,
+			"reason", transferErrorCategory(err),
+			"source", sourceFromContext(ctx),
+			"error", err.Error(),
+		)
+		return
+	}
+
+	logger.Info("transfer",
+		"from", transfer.FromAddress,
+		"to", transfer.ToAddress,
+		"asset", transfer.Asset,
+		"amount", transfer.Amount,
+		"new_sender_balance", transfer.SenderBalance,
+		"timestamp", transfer.Timestamp,
+	)
+}
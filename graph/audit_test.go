@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+func TestAuditTransfer(t *testing.T) {
+	var logs, general bytes.Buffer
+	resolver := &Resolver{
+		WalletTable: "wallets",
+		Logger:      slog.New(slog.NewJSONHandler(&general, nil)),
+		AuditLogger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+
+	var entry map[string]any
+	readEntry := func() {
+		t.Helper()
+		line, err := logs.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Expected audit log line, got: %v", err)
+		}
+		entry = nil
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("Invalid audit log line %q: %v", line, err)
+		}
+	}
+
+	// Successful transfer, tagged with the request ID
+	resolver.auditTransfer(WithRequestID(context.Background(), "req-1"), "0xa000000000000000000000000000000000000000", "0xb000000000000000000000000000000000000000", "TOKEN", "1.5", &model.TransferResult{
+		FromAddress:   "0xa000000000000000000000000000000000000000",
+		ToAddress:     "0xb000000000000000000000000000000000000000",
+		Asset:         "TOKEN",
+		SenderBalance: "8.500000000000000000",
+		Amount:        "1.500000000000000000",
+		Timestamp:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, nil)
+	readEntry()
+	expected := map[string]any{
+		"level":              "INFO",
+		"msg":                "transfer",
+		"from":               "0xa000000000000000000000000000000000000000",
+		"to":                 "0xb000000000000000000000000000000000000000",
+		"asset":              "TOKEN",
+		"amount":             "1.500000000000000000",
+		"new_sender_balance": "8.500000000000000000",
+		"timestamp":          "2025-01-02T03:04:05Z",
+		"request_id":         "req-1",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+
+	// Failed transfer, rejected before any DB call
+	_, err := resolver.Service().Transfer(context.Background(), "0xa000000000000000000000000000000000000000", "0xb000000000000000000000000000000000000000", "-1")
+	if err == nil {
+		t.Fatal("Expected transfer to fail")
+	}
+	readEntry()
+	if entry["level"] != "WARN" || entry["msg"] != "transfer failed" || entry["reason"] != "invalid_amount" || entry["error"] != err.Error() || entry["amount"] != "-1" {
+		t.Errorf("Unexpected audit log line for failed transfer: %v", entry)
+	}
+	if _, ok := entry["request_id"]; ok {
+		t.Errorf("Expected no request_id without one in context, got: %v", entry)
+	}
+	if entry["source"] != "unknown" {
+		t.Errorf("Expected source unknown without client in context, got: %v", entry["source"])
+	}
+
+	// Failed transfers are attributed to the API key, or the client IP without one
+	clientCtx := WithClientIP(context.Background(), "203.0.113.7")
+	for _, c := range []struct {
+		ctx    context.Context
+		source string
+	}{
+		{clientCtx, "203.0.113.7"},
+		{WithActor(clientCtx, "api-key-1"), "api-key-1"},
+	} {
+		if _, err := resolver.Service().Transfer(c.ctx, "0xa000000000000000000000000000000000000000", "0xb000000000000000000000000000000000000000", "-1"); err == nil {
+			t.Fatal("Expected transfer to fail")
+		}
+		readEntry()
+		if entry["source"] != c.source {
+			t.Errorf("Expected source %s, got: %v", c.source, entry["source"])
+		}
+	}
+
+	// Audit lines do not go to the general logger when AuditLogger is set
+	if general.Len() > 0 {
+		t.Errorf("Expected no general log output, got: %s", general.String())
+	}
+}
+
+func TestMetricSourceFromContext(t *testing.T) {
+	// Client IPs never become label values
+	clientCtx := WithClientIP(context.Background(), "203.0.113.7")
+	if got := metricSourceFromContext(clientCtx); got != "anonymous" {
+		t.Errorf("Expected anonymous for unauthenticated client, got %s", got)
+	}
+	if got := metricSourceFromContext(WithActor(clientCtx, "api-key-1")); got != "api-key-1" {
+		t.Errorf("Expected api-key-1, got %s", got)
+	}
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"token_transfer/graph/model"
+
+	"github.com/lib/pq"
+)
+
+// Max addresses in one balances query
+const maxBalanceAddresses = 1000
+
+// Base asset balances of many wallets with one query, in the order of addresses
+// Missing wallets are reported with balance "0" and found=false; duplicates are answered once per occurrence
+func (r *Resolver) walletBalances(ctx context.Context, addresses []string) ([]*model.WalletBalance, error) {
+	if len(addresses) > maxBalanceAddresses {
+		return nil, fmt.Errorf("at most %d addresses allowed", maxBalanceAddresses)
+	}
+
+	normalized := make([]string, len(addresses))
+	for i, address := range addresses {
+		if err := validateEthereumAddress(address); err != nil {
+			r.recordValidationFailure(ctx, err)
+			return nil, fmt.Errorf("address %d invalid: %w", i, err)
+		}
+		normalized[i] = normalizeAddress(address)
+	}
+
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %[2]s, %[3]s FROM %[1]s WHERE %[2]s = ANY($1) AND asset = $2", t.Wallets, t.AddressCol, t.BalanceCol)
+	rows, err := r.readDB().QueryContext(ctx, query, pq.Array(normalized), r.baseAsset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[string]string, len(normalized))
+	for rows.Next() {
+		var address, balance string
+		if err := rows.Scan(&address, &balance); err != nil {
+			return nil, err
+		}
+		balances[address] = balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.WalletBalance, len(normalized))
+	for i, address := range normalized {
+		balance, found := balances[address]
+		if !found {
+			balance = "0"
+		}
+		result[i] = &model.WalletBalance{Address: address, Balance: balance, Found: found}
+	}
+	return result, nil
+}
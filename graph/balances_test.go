@@ -0,0 +1,26 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWalletBalancesRejectsInput(t *testing.T) {
+	// No DB: rejected input must fail before any query
+	r := &Resolver{WalletTable: "wallets"}
+	ctx := context.Background()
+	valid := "0xa000000000000000000000000000000000000000"
+
+	addresses := make([]string, maxBalanceAddresses+1)
+	for i := range addresses {
+		addresses[i] = valid
+	}
+	if _, err := r.walletBalances(ctx, addresses); err == nil {
+		t.Error("Expected error for too many addresses")
+	}
+
+	if _, err := r.walletBalances(ctx, []string{valid, "0x123"}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Max number of transfers committed in one batch when BatchMaxSize is not set
+const defaultBatchMaxSize = 100
+
+// Transfer waiting to be committed in a batch
+type batchRequest struct {
+	ctx                   context.Context // carries trace of the request; batch is not cancelled by it
+	fromAddress           string
+	toAddress             string
+	asset                 string
+	amount                string
+	expectedSenderBalance *string
+	memo                  string
+	result                chan batchResult
+}
+
+type batchResult struct {
+	transferResult
+	err error
+}
+
+// Groups transfers arriving within BatchWindow into a single DB transaction
+type transferBatcher struct {
+	once     sync.Once
+	requests chan *batchRequest
+}
+
+// Queue transfer for the next batch and wait until the batch is committed
+func (r *Resolver) batchedTransfer(ctx context.Context, fromAddress, toAddress, asset, amount string, expectedSenderBalance *string, memo string) (transferResult, error) {
+	r.batcher.once.Do(func() {
+		r.batcher.requests = make(chan *batchRequest)
+		go r.runBatcher(r.batcher.requests)
+	})
+
+	request := &batchRequest{
+		ctx:                   ctx,
+		fromAddress:           fromAddress,
+		toAddress:             toAddress,
+		asset:                 asset,
+		amount:                amount,
+		expectedSenderBalance: expectedSenderBalance,
+		memo:                  memo,
+		result:                make(chan batchResult, 1),
+	}
+
+	select {
+	case r.batcher.requests <- request:
+	case <-ctx.Done():
+		return transferResult{}, ctx.Err()
+	}
+
+	// Once queued, the transfer is part of a batch and its outcome must be awaited
+	result := <-request.result
+	return result.transferResult, result.err
+}
+
+// Collect transfers for BatchWindow (or until batch is full) and commit them together
+func (r *Resolver) runBatcher(requests chan *batchRequest) {
+	maxSize := r.BatchMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultBatchMaxSize
+	}
+
+	for first := range requests {
+		batch := []*batchRequest{first}
+		window := time.NewTimer(r.BatchWindow)
+
+	collect:
+		for len(batch) < maxSize {
+			select {
+			case request := <-requests:
+				batch = append(batch, request)
+			case <-window.C:
+				break collect
+			}
+		}
+		window.Stop()
+
+		r.commitBatch(batch)
+	}
+}
+
+// Run every transfer of a batch in its own savepoint of one DB transaction
+// A failed transfer is rolled back to its savepoint and does not affect the others
+// A serialization failure or deadlock runs the whole batch again, like a single transfer
+func (r *Resolver) commitBatch(batch []*batchRequest) {
+	results := make([]batchResult, len(batch))
+	defer func() {
+		for i, request := range batch {
+			request.result <- results[i]
+		}
+	}()
+
+	// Batch is shared, so no single request can cancel it
+	ctx := context.Background()
+	err := r.retryTransferTx(ctx, func() error {
+		return r.batchAttempt(ctx, batch, results)
+	})
+	if err != nil {
+		// Transfers that succeeded inside the batch are lost with it
+		for i := range results {
+			if results[i].err == nil {
+				results[i] = batchResult{err: err}
+			}
+		}
+	}
+}
+
+// Run batch in a new DB transaction and commit it, filling in results of its transfers
+// Returns an error that fails the whole batch: a failed savepoint or commit, or a transfer Postgres aborted
+func (r *Resolver) batchAttempt(ctx context.Context, batch []*batchRequest, results []batchResult) error {
+	clear(results)
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer r.rollback(tx)
+
+	if err := r.setLockTimeout(ctx, tx); err != nil {
+		return err
+	}
+
+	// Lock every wallet of the batch before the first transfer, in the same order as single transfers do;
+	// each transfer then only takes locks the batch already holds, and the chain head comes after all of them
+	if err := r.lockBatchWallets(ctx, tx, batch); err != nil {
+		return transferTimeoutError(ctx, err)
+	}
+
+	for i, request := range batch {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batched_transfer"); err != nil {
+			return err
+		}
+
+		// Batch is shared, so a cancelled request must not abort its statements
+		result, err := r.transferInTx(context.WithoutCancel(request.ctx), tx, request.fromAddress, request.toAddress, request.asset, request.amount, request.expectedSenderBalance, request.memo)
+		if err != nil {
+			if retryableReason(err) != "" {
+				return err
+			}
+			results[i] = batchResult{err: err}
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batched_transfer"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batched_transfer"); err != nil {
+			return err
+		}
+		results[i] = batchResult{transferResult: result}
+	}
+
+	return tx.Commit()
+}
+
+// Lock the wallets of every transfer in a batch with the configured LockStrategy
+// Advisory keys are taken in ascending order and wallet rows in (address, asset) order,
+// so batches and single transfers always wait on each other in a consistent order
+func (r *Resolver) lockBatchWallets(ctx context.Context, tx *sql.Tx, batch []*batchRequest) error {
+	if r.LockStrategy == LockRow {
+		var addresses, assets []string
+		for _, request := range batch {
+			addresses = append(addresses, request.fromAddress, request.toAddress)
+			assets = append(assets, request.asset, request.asset)
+		}
+		t := r.tables()
+		query := fmt.Sprintf(`SELECT %[2]s FROM %[1]s
+			WHERE (%[2]s, asset) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+			ORDER BY %[2]s, asset FOR UPDATE`, t.Wallets, t.AddressCol)
+		rows, err := tx.QueryContext(ctx, query, pq.Array(addresses), pq.Array(assets))
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	}
+
+	var keys []int64
+	for _, request := range batch {
+		keys = append(keys, lockKeys(r.lockName(request.fromAddress, request.asset), r.lockName(request.toAddress, request.asset))...)
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		if err := r.lockHashAddress(ctx, tx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
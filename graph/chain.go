@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"token_transfer/graph/model"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/shopspring/decimal"
+)
+
+// prev_hash of the first transaction in the chain
+const genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Counterparty of mints, burns and the initial supply in the transaction log
+// Not an Ethereum address, so no wallet can ever have it
+const supplyAddress = "supply"
+
+// Timestamp layout used in hashes; Postgres keeps microseconds
+const hashTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// Receipt hash = SHA-256 over (sequence, from, to, amount, timestamp, prevHash[, asset])
+// amount must be in the NUMERIC(28,18) form returned by the DB
+// asset is appended only when not empty, i.e. not the base asset (see hashAsset)
+func receiptHash(sequence int64, fromAddress, toAddress, amount string, createdAt time.Time, prevHash, asset string) string {
+	payload := fmt.Sprintf("%d|%s|%s|%s|%s|%s",
+		sequence,
+		fromAddress,
+		toAddress,
+		amount,
+		createdAt.UTC().Format(hashTimeLayout),
+		prevHash,
+	)
+	if asset != "" {
+		payload += "|" + asset
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// One-row table holding the hash of the last transaction in the log
+// Its row lock is what makes appends to the log run one at a time
+func (r *Resolver) chainHeadTable() string {
+	return r.TransactionTable + "_head"
+}
+
+// Append transfer to the transaction log, chained to the previous transaction
+// Memo is stored as NULL when empty and is not part of the hash
+// Returns the receipt hash and the stored timestamp of the new transaction
+//
+// Every append locks the chain head row until commit, so logged transfers commit one at a time.
+// Callers make this the last step of a transfer, after wallet locks and balance updates, so the
+// row is held only for the log insert and the commit; that commit latency still caps throughput.
+func (r *Resolver) recordTransaction(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset, amount, memo string) (string, time.Time, error) {
+	// Hash of the last transaction; a concurrent append waits here until this transaction ends
+	var prevHash string
+	query := fmt.Sprintf("SELECT hash FROM %s WHERE id = 1 FOR UPDATE", r.chainHeadTable())
+	if err := tx.QueryRowContext(ctx, query).Scan(&prevHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", time.Time{}, fmt.Errorf("chain head %s has no row", r.chainHeadTable())
+		}
+		return "", time.Time{}, err
+	}
+
+	var sequence int64
+	if err := tx.QueryRowContext(ctx, "SELECT nextval(pg_get_serial_sequence($1, 'id'))", r.TransactionTable).Scan(&sequence); err != nil {
+		return "", time.Time{}, err
+	}
+
+	// Amount as stored in NUMERIC(28,18)
+	amountDecimal, err := decimal.NewFromString(amount)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	storedAmount := amountDecimal.StringFixed(18)
+
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	hash := receiptHash(sequence, fromAddress, toAddress, storedAmount, createdAt, prevHash, r.hashAsset(asset))
+
+	query = fmt.Sprintf(`INSERT INTO %s (id, from_address, to_address, asset, amount, created_at, prev_hash, hash, memo)
+		VALUES ($1, $2, $3, $4, $5::numeric, $6, $7, $8, NULLIF($9, ''))`, r.TransactionTable)
+	_, err = tx.ExecContext(ctx, query, sequence, fromAddress, toAddress, asset, storedAmount, createdAt, prevHash, hash, memo)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := r.setChainHead(ctx, tx, hash); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return hash, createdAt, nil
+}
+
+// Point the chain head at the transaction with given hash
+func (r *Resolver) setChainHead(ctx context.Context, tx *sql.Tx, hash string) error {
+	query := fmt.Sprintf("UPDATE %s SET hash = $1 WHERE id = 1", r.chainHeadTable())
+	result, err := tx.ExecContext(ctx, query, hash)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("chain head %s has no row", r.chainHeadTable())
+	}
+	return nil
+}
+
+// Walk the transaction log in order and find the first broken link
+func (r *Resolver) verifyChain(ctx context.Context) (*model.ChainVerification, error) {
+	query := fmt.Sprintf(`SELECT id, from_address, to_address, asset, amount, created_at, prev_hash, hash
+		FROM %s ORDER BY id`, r.TransactionTable)
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &model.ChainVerification{Valid: true}
+	expectedPrevHash := genesisHash
+
+	for rows.Next() {
+		var (
+			id                     int64
+			fromAddress, toAddress string
+			asset, amount          string
+			createdAt              time.Time
+			prevHash, hash         string
+		)
+		if err := rows.Scan(&id, &fromAddress, &toAddress, &asset, &amount, &createdAt, &prevHash, &hash); err != nil {
+			return nil, err
+		}
+
+		// Link to previous transaction and content of this one must both match
+		if prevHash != expectedPrevHash ||
+			receiptHash(id, fromAddress, toAddress, amount, createdAt, prevHash, r.hashAsset(asset)) != hash {
+			brokenAt := fmt.Sprint(id)
+			result.Valid = false
+			result.BrokenAt = &brokenAt
+			return result, nil
+		}
+
+		result.Checked++
+		expectedPrevHash = hash
+	}
+
+	return result, rows.Err()
+}
+
+// Expose receipt hash in the "receipts" response extension, keyed by field alias
+// Does nothing for empty hash or when resolver is called outside of a GraphQL operation
+func registerReceipt(ctx context.Context, hash string) {
+	fieldCtx := graphql.GetFieldContext(ctx)
+	if hash == "" || fieldCtx == nil {
+		return
+	}
+
+	receipts, ok := graphql.GetExtension(ctx, "receipts").(map[string]string)
+	if !ok {
+		receipts = map[string]string{}
+		graphql.RegisterExtension(ctx, "receipts", receipts)
+	}
+	receipts[fieldCtx.Field.Alias] = hash
+}
@@ -0,0 +1,23 @@
+package graph
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitialSupplyReceipt(t *testing.T) {
+	// db/init.sql logs the initial supply with a precomputed hash, which must match receiptHash
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	hash := receiptHash(1, supplyAddress, "0x0000000000000000000000000000000000000000", "1000000.000000000000000000", createdAt, genesisHash, "")
+
+	initSQL, err := os.ReadFile("../db/init.sql")
+	if err != nil {
+		t.Fatalf("Failed to read init.sql: %v", err)
+	}
+	// Once in the transaction and once in the chain head
+	if count := strings.Count(string(initSQL), "'"+hash+"'"); count != 2 {
+		t.Errorf("Expected hash %s twice in init.sql, found it %d times", hash, count)
+	}
+}
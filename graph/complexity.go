@@ -0,0 +1,34 @@
+package graph
+
+import (
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+// Complexity of list fields, scaled by the most items they can return
+// Other fields keep the gqlgen default of 1 plus their children
+func ListComplexity() ComplexityRoot {
+	var c ComplexityRoot
+	c.Query.Wallets = func(childComplexity int, first *int32, after *string) int {
+		return pageSize(first, defaultWalletPage, maxWalletPage) * (childComplexity + 1)
+	}
+	c.Query.Transactions = func(childComplexity int, address string, direction *model.TransferDirection, from *time.Time, to *time.Time, first *int32, after *string) int {
+		return pageSize(first, defaultTransactionPage, maxTransactionPage) * (childComplexity + 1)
+	}
+	c.Query.Balances = func(childComplexity int, addresses []string) int {
+		return max(len(addresses), 1) * (childComplexity + 1)
+	}
+	c.Query.FlowMatrix = func(childComplexity int, from time.Time, to time.Time, topN *int32) int {
+		return pageSize(topN, defaultFlowEdges, maxFlowEdges) * (childComplexity + 1)
+	}
+	return c
+}
+
+// Requested page size clamped to [1, maxSize]; out of range values are rejected by the resolver anyway
+func pageSize(first *int32, defaultSize, maxSize int) int {
+	if first == nil {
+		return defaultSize
+	}
+	return min(max(int(*first), 1), maxSize)
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// Sentinel errors for callers matching with errors.Is
+// Returned errors keep their own human-readable messages and wrap one of these
+var (
+	ErrInsufficientBalance   = errors.New("insufficient balance")
+	ErrInvalidAddress        = errors.New("invalid address")
+	ErrSameAddress           = errors.New("same address")
+	ErrInvalidAmount         = errors.New("invalid amount")
+	ErrTransferTimeout       = errors.New("transfer timed out")
+	ErrRateLimitExceeded     = errors.New("rate limit exceeded")
+	ErrWalletFrozen          = errors.New("wallet is frozen")
+	ErrWriteConflict         = errors.New("wallet was modified concurrently")
+	ErrInsufficientAllowance = errors.New("insufficient allowance")
+)
+
+// Missing wallet with a message for clients; still matches sql.ErrNoRows
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+func (e *notFoundError) Unwrap() error {
+	return sql.ErrNoRows
+}
+
+// Category of an error returned by a resolver, e.g. "insufficient_balance" or "db_error"
+// Same values as the category label of transfer_failures_total
+func ErrorCategory(err error) string {
+	return transferErrorCategory(err)
+}
+
+// Sentinel error wrapped by validationError, by reason
+var validationSentinels = map[string]error{
+	"invalid_address":     ErrInvalidAddress,
+	"same_address":        ErrSameAddress,
+	"invalid_amount":      ErrInvalidAmount,
+	"non_positive_amount": ErrInvalidAmount,
+	"too_many_decimals":   ErrInvalidAmount,
+	"too_many_digits":     ErrInvalidAmount,
+	"invalid_decimals":    ErrInvalidAmount,
+	"invalid_units":       ErrInvalidAmount,
+	"amount_too_small":    ErrInvalidAmount,
+	"amount_too_large":    ErrInvalidAmount,
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Format decimal for display: trailing zeros trimmed, integer part grouped by thousands
+// Separators are applied explicitly, so output does not depend on Go or OS locale
+func formatDecimal(value decimal.Decimal, decimalSeparator, groupSeparator string) string {
+	integerPart, fractionPart, _ := strings.Cut(value.Abs().String(), ".")
+
+	var formatted strings.Builder
+	if value.IsNegative() {
+		formatted.WriteString("-")
+	}
+	for i, digit := range integerPart {
+		if i > 0 && (len(integerPart)-i)%3 == 0 {
+			formatted.WriteString(groupSeparator)
+		}
+		formatted.WriteRune(digit)
+	}
+	if fractionPart != "" {
+		formatted.WriteString(decimalSeparator)
+		formatted.WriteString(fractionPart)
+	}
+
+	return formatted.String()
+}
+
+// Separators from arguments, falling back to configured ones and then to "." and ","
+func (r *Resolver) separators(decimalSeparator, groupSeparator *string) (string, string) {
+	decimalSep, groupSep := ".", ","
+	if r.DecimalSeparator != "" {
+		decimalSep = r.DecimalSeparator
+	}
+	if r.GroupSeparator != "" {
+		groupSep = r.GroupSeparator
+	}
+
+	if decimalSeparator != nil {
+		decimalSep = *decimalSeparator
+	}
+	if groupSeparator != nil {
+		groupSep = *groupSeparator
+	}
+	return decimalSep, groupSep
+}
@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+// Page size of transactions query
+const (
+	defaultTransactionPage = 10
+	maxTransactionPage     = 100
+)
+
+// Filters of the transactions query; nil bounds leave the range open on that side
+type transactionFilter struct {
+	address   string
+	direction model.TransferDirection
+	from      *time.Time
+	to        *time.Time
+}
+
+// Cursor is the base64-encoded "timestamp|id" of the last transaction on the page
+// Transactions are ordered by both, so the position stays stable while new ones are appended
+func encodeTransactionCursor(timestamp time.Time, id int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(timestamp.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(id, 10)))
+}
+
+func decodeTransactionCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	timestampPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, timestampPart)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid cursor")
+	}
+	return timestamp, id, nil
+}
+
+// Return up to first transactions matching filter, after the one in after cursor
+func (r *Resolver) listTransactions(ctx context.Context, filter transactionFilter, first *int32, after *string) (*model.TransactionConnection, error) {
+	limit := int32(defaultTransactionPage)
+	if first != nil {
+		limit = *first
+	}
+	if limit <= 0 || limit > maxTransactionPage {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxTransactionPage)
+	}
+	if filter.from != nil && filter.to != nil && filter.to.Before(*filter.from) {
+		return nil, fmt.Errorf("invalid time range: from must not be after to")
+	}
+
+	// Conditions reference args by position; values are never put into the SQL
+	args := []any{filter.address}
+	var conditions []string
+	switch filter.direction {
+	case model.TransferDirectionSent:
+		conditions = append(conditions, "from_address = $1")
+	case model.TransferDirectionReceived:
+		conditions = append(conditions, "to_address = $1")
+	case model.TransferDirectionAll:
+		conditions = append(conditions, "(from_address = $1 OR to_address = $1)")
+	default:
+		return nil, fmt.Errorf("invalid direction %q", filter.direction)
+	}
+	if filter.from != nil {
+		args = append(args, *filter.from)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.to != nil {
+		args = append(args, *filter.to)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if after != nil {
+		afterTimestamp, afterID, err := decodeTransactionCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, afterTimestamp, afterID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	// Fetch one extra row to know if there is a next page
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`SELECT id, from_address, to_address, asset, amount, created_at, hash, memo FROM %s
+		WHERE %s ORDER BY created_at, id LIMIT $%d`, r.TransactionTable, strings.Join(conditions, " AND "), len(args))
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connection := &model.TransactionConnection{
+		Edges:    []*model.TransactionEdge{},
+		PageInfo: &model.PageInfo{},
+	}
+	for rows.Next() {
+		var id int64
+		transaction := &model.Transaction{}
+		err := rows.Scan(&id, &transaction.FromAddress, &transaction.ToAddress, &transaction.Asset, &transaction.Amount,
+			&transaction.Timestamp, &transaction.Hash, &transaction.Memo)
+		if err != nil {
+			return nil, err
+		}
+		if int32(len(connection.Edges)) == limit {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+		transaction.ID = strconv.FormatInt(id, 10)
+		transaction.Timestamp = transaction.Timestamp.UTC()
+		connection.Edges = append(connection.Edges, &model.TransactionEdge{
+			Node:   transaction,
+			Cursor: encodeTransactionCursor(transaction.Timestamp, id),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if count := len(connection.Edges); count > 0 {
+		endCursor := connection.Edges[count-1].Cursor
+		connection.PageInfo.EndCursor = &endCursor
+	}
+	return connection, nil
+}
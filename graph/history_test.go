@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	timestamp := time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	decodedTimestamp, id, err := decodeTransactionCursor(encodeTransactionCursor(timestamp, 42))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decodedTimestamp.Equal(timestamp) || id != 42 {
+		t.Errorf("Expected %s/42, got %s/%d", timestamp, decodedTimestamp, id)
+	}
+
+	for _, cursor := range []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("no separator")),
+		base64.StdEncoding.EncodeToString([]byte("yesterday|1")),
+		base64.StdEncoding.EncodeToString([]byte("2025-01-02T03:04:05Z|x")),
+	} {
+		if _, _, err := decodeTransactionCursor(cursor); err == nil {
+			t.Errorf("%q: expected error", cursor)
+		}
+	}
+}
+
+func TestListTransactionsRejectsInput(t *testing.T) {
+	// No DB: rejected input must fail before any query
+	r := &Resolver{WalletTable: "wallets", TransactionTable: "transactions"}
+	ctx := context.Background()
+	filter := transactionFilter{address: "0xa000000000000000000000000000000000000000", direction: model.TransferDirectionAll}
+
+	for _, first := range []int32{0, maxTransactionPage + 1} {
+		if _, err := r.listTransactions(ctx, filter, &first, nil); err == nil {
+			t.Errorf("first %d: expected error", first)
+		}
+	}
+
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+	reversed := filter
+	reversed.from, reversed.to = &from, &to
+	if _, err := r.listTransactions(ctx, reversed, nil, nil); err == nil {
+		t.Error("Expected error for reversed time range")
+	}
+
+	unknown := filter
+	unknown.direction = "SIDEWAYS"
+	if _, err := r.listTransactions(ctx, unknown, nil, nil); err == nil {
+		t.Error("Expected error for unknown direction")
+	}
+
+	cursor := "not-a-cursor"
+	if _, err := r.listTransactions(ctx, filter, nil, &cursor); err == nil {
+		t.Error("Expected error for invalid cursor")
+	}
+}
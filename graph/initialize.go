@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Create treasury wallet holding the initial supply of the base asset, logged as coming from supplyAddress
+// Can be done exactly once: fails with "already initialized" if treasury wallet exists
+func (r *Resolver) Initialize(ctx context.Context, initialSupply decimal.Decimal) error {
+	if err := validateEthereumAddress(r.TreasuryAddress); err != nil {
+		return fmt.Errorf("treasury address invalid: %w", err)
+	}
+
+	if err := validateTokenAmount(initialSupply.String()); err != nil {
+		return fmt.Errorf("initial supply invalid: %w", err)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer r.rollback(tx)
+
+	// Insert fails silently if treasury already exists, even under concurrent init
+	treasury := normalizeAddress(r.TreasuryAddress)
+	t := r.tables()
+	query := fmt.Sprintf(`INSERT INTO %[1]s (%[2]s, asset, %[3]s) VALUES ($1, $2, $3::numeric)
+		ON CONFLICT (%[2]s, asset) DO NOTHING`, t.Wallets, t.AddressCol, t.BalanceCol)
+	result, err := tx.ExecContext(ctx, query, treasury, r.baseAsset(), initialSupply.String())
+	if err != nil {
+		return err
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return fmt.Errorf("already initialized")
+	}
+
+	// Initial supply is logged like a mint, so the treasury reconciles against the log
+	if r.TransactionTable != "" {
+		if _, _, err := r.recordTransaction(ctx, tx, supplyAddress, treasury, r.baseAsset(), initialSupply.String(), ""); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,361 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"token_transfer/graph/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Version of the ledger export format
+const ledgerVersion = 1
+
+// Ledger export is NDJSON: one header line, then wallets ordered by address,
+// then transactions ordered by ID. Each line carries its record type
+type ledgerHeader struct {
+	Type         string `json:"type"`
+	Version      int    `json:"version"`
+	Supply       string `json:"supply"` // sum of all base asset wallet balances
+	Wallets      int    `json:"wallets"`
+	Transactions int    `json:"transactions"`
+}
+
+// Records without asset, from exports made before assets existed, belong to the base asset
+type ledgerWallet struct {
+	Type    string  `json:"type"`
+	Address string  `json:"address"`
+	Asset   string  `json:"asset,omitempty"`
+	Balance string  `json:"balance"`
+	OwnerID *string `json:"owner_id,omitempty"`
+	Frozen  bool    `json:"frozen,omitempty"`
+}
+
+type ledgerTransaction struct {
+	Type        string    `json:"type"`
+	ID          int64     `json:"id"`
+	FromAddress string    `json:"from_address"`
+	ToAddress   string    `json:"to_address"`
+	Asset       string    `json:"asset,omitempty"`
+	Amount      string    `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	PrevHash    string    `json:"prev_hash"`
+	Hash        string    `json:"hash"`
+	Memo        *string   `json:"memo,omitempty"`
+}
+
+// Export wallets and transaction log as one consistent snapshot
+func (r *Resolver) exportLedger(ctx context.Context) (string, error) {
+	// Repeatable read: both tables are read from the same snapshot
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return "", err
+	}
+	defer r.rollback(tx)
+
+	header := ledgerHeader{Type: "header", Version: ledgerVersion}
+	supply := decimal.Zero
+
+	var lines []any
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s, asset", r.walletColumns(), t.Wallets, t.AddressCol)
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+		balance, err := decimal.NewFromString(wallet.Balance)
+		if err != nil {
+			rows.Close()
+			return "", fmt.Errorf("invalid balance format in DB")
+		}
+		if wallet.Asset == r.baseAsset() {
+			supply = supply.Add(balance)
+		}
+		lines = append(lines, ledgerWallet{Type: "wallet", Address: wallet.Address, Asset: wallet.Asset, Balance: wallet.Balance, OwnerID: wallet.OwnerID, Frozen: wallet.Frozen})
+		header.Wallets++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if r.TransactionTable != "" {
+		query = fmt.Sprintf(`SELECT id, from_address, to_address, asset, amount, created_at, prev_hash, hash, memo
+			FROM %s ORDER BY id`, r.TransactionTable)
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		for rows.Next() {
+			record := ledgerTransaction{Type: "transaction"}
+			err := rows.Scan(&record.ID, &record.FromAddress, &record.ToAddress, &record.Asset, &record.Amount,
+				&record.CreatedAt, &record.PrevHash, &record.Hash, &record.Memo)
+			if err != nil {
+				rows.Close()
+				return "", err
+			}
+			record.CreatedAt = record.CreatedAt.UTC()
+			lines = append(lines, record)
+			header.Transactions++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+	}
+	header.Supply = supply.StringFixed(18)
+
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	if err := encoder.Encode(header); err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			return "", err
+		}
+	}
+
+	return out.String(), nil
+}
+
+// Restore ledger export into empty tables
+// Whole ledger is validated before anything is written: record counts, supply
+// equal to the sum of base asset balances, an unbroken hash chain and balances
+// matching the imported transactions
+func (r *Resolver) importLedger(ctx context.Context, ledger string) (*model.LedgerSummary, error) {
+	header, wallets, transactions, err := parseLedger(ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) > 0 && r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	if err := r.validateLedger(header, wallets, transactions); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.rollback(tx)
+
+	// Import never merges into existing data
+	tables := []string{r.tables().Wallets}
+	if r.TransactionTable != "" {
+		tables = append(tables, r.TransactionTable)
+	}
+	for _, table := range tables {
+		// Lock out concurrent writers until commit
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", table)); err != nil {
+			return nil, err
+		}
+		var notEmpty bool
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", table)).Scan(&notEmpty); err != nil {
+			return nil, err
+		}
+		if notEmpty {
+			return nil, fmt.Errorf("ledger import requires empty tables: %s is not empty", table)
+		}
+	}
+
+	t := r.tables()
+	query := fmt.Sprintf("INSERT INTO %s (%s, asset, %s, owner_id, frozen) VALUES ($1, $2, $3::numeric, $4, $5)", t.Wallets, t.AddressCol, t.BalanceCol)
+	for _, wallet := range wallets {
+		if _, err := tx.ExecContext(ctx, query, wallet.Address, wallet.Asset, wallet.Balance, wallet.OwnerID, wallet.Frozen); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(transactions) > 0 {
+		query = fmt.Sprintf(`INSERT INTO %s (id, from_address, to_address, asset, amount, created_at, prev_hash, hash, memo)
+			VALUES ($1, $2, $3, $4, $5::numeric, $6, $7, $8, $9)`, r.TransactionTable)
+		for _, record := range transactions {
+			_, err := tx.ExecContext(ctx, query, record.ID, record.FromAddress, record.ToAddress, record.Asset, record.Amount,
+				record.CreatedAt, record.PrevHash, record.Hash, record.Memo)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Continue ID sequence and hash chain after the imported log
+		last := transactions[len(transactions)-1]
+		if _, err := tx.ExecContext(ctx, "SELECT setval(pg_get_serial_sequence($1, 'id'), $2)", r.TransactionTable, last.ID); err != nil {
+			return nil, err
+		}
+		if err := r.setChainHead(ctx, tx, last.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &model.LedgerSummary{
+		Wallets:      int32(len(wallets)),
+		Transactions: int32(len(transactions)),
+		Supply:       header.Supply,
+	}, nil
+}
+
+// Split NDJSON ledger into header, wallets and transactions
+func parseLedger(ledger string) (*ledgerHeader, []ledgerWallet, []ledgerTransaction, error) {
+	var (
+		header       *ledgerHeader
+		wallets      []ledgerWallet
+		transactions []ledgerTransaction
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(ledger))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var record struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid ledger line %d: %v", lineNumber, err)
+		}
+
+		var err error
+		switch {
+		case record.Type == "header" && header == nil && len(wallets) == 0 && len(transactions) == 0:
+			header = &ledgerHeader{}
+			err = json.Unmarshal(line, header)
+		case record.Type == "wallet" && header != nil && len(transactions) == 0:
+			var wallet ledgerWallet
+			err = json.Unmarshal(line, &wallet)
+			wallets = append(wallets, wallet)
+		case record.Type == "transaction" && header != nil:
+			var transaction ledgerTransaction
+			err = json.Unmarshal(line, &transaction)
+			transactions = append(transactions, transaction)
+		default:
+			return nil, nil, nil, fmt.Errorf("unexpected %q record on ledger line %d", record.Type, lineNumber)
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid ledger line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if header == nil {
+		return nil, nil, nil, fmt.Errorf("ledger header missing")
+	}
+	return header, wallets, transactions, nil
+}
+
+// Check ledger invariants before import; fills in the base asset of records without one
+// and lowercases wallet addresses
+func (r *Resolver) validateLedger(header *ledgerHeader, wallets []ledgerWallet, transactions []ledgerTransaction) error {
+	if header.Version != ledgerVersion {
+		return fmt.Errorf("unsupported ledger version %d", header.Version)
+	}
+
+	if header.Wallets != len(wallets) || header.Transactions != len(transactions) {
+		return fmt.Errorf("ledger record count mismatch")
+	}
+
+	// Wallets are stored under their canonical address, so spellings of one wallet collide;
+	// transactions keep the addresses their receipt hashes were computed over
+	supply := decimal.Zero
+	seen := make(map[string]bool, len(wallets))
+	for i := range wallets {
+		wallet := &wallets[i]
+		if err := validateEthereumAddress(wallet.Address); err != nil {
+			return fmt.Errorf("wallet %s: %w", wallet.Address, err)
+		}
+		wallet.Address = normalizeAddress(wallet.Address)
+		wallet.Asset = r.assetOrBase(&wallet.Asset)
+		if err := validateAsset(wallet.Asset); err != nil {
+			return fmt.Errorf("wallet %s: %w", wallet.Address, err)
+		}
+		key := r.lockName(wallet.Address, wallet.Asset)
+		if seen[key] {
+			return fmt.Errorf("wallet %s: duplicate wallet", wallet.Address)
+		}
+		seen[key] = true
+		balance, err := decimal.NewFromString(wallet.Balance)
+		if err != nil || balance.IsNegative() {
+			return fmt.Errorf("wallet %s: invalid balance", wallet.Address)
+		}
+		if wallet.Asset == r.baseAsset() {
+			supply = supply.Add(balance)
+		}
+	}
+
+	headerSupply, err := decimal.NewFromString(header.Supply)
+	if err != nil || !headerSupply.Equal(supply) {
+		return fmt.Errorf("ledger supply does not match wallet balances")
+	}
+
+	// Same check as verifyChain, done before anything is written
+	expectedPrevHash := genesisHash
+	for i := range transactions {
+		record := &transactions[i]
+		record.Asset = r.assetOrBase(&record.Asset)
+		if record.PrevHash != expectedPrevHash ||
+			receiptHash(record.ID, record.FromAddress, record.ToAddress, record.Amount, record.CreatedAt, record.PrevHash, r.hashAsset(record.Asset)) != record.Hash {
+			return fmt.Errorf("ledger hash chain broken at transaction %d", record.ID)
+		}
+		expectedPrevHash = record.Hash
+	}
+
+	// Like reconcileWallet: every balance is what the log credited minus what it debited.
+	// A ledger without transactions comes from a DB without history and has no log to check against
+	if len(transactions) == 0 {
+		return nil
+	}
+	nets := make(map[string]decimal.Decimal)
+	for _, record := range transactions {
+		amount, err := decimal.NewFromString(record.Amount)
+		if err != nil {
+			return fmt.Errorf("transaction %d: invalid amount", record.ID)
+		}
+		if record.FromAddress != supplyAddress {
+			key := r.lockName(normalizeAddress(record.FromAddress), record.Asset)
+			nets[key] = nets[key].Sub(amount)
+		}
+		if record.ToAddress != supplyAddress {
+			key := r.lockName(normalizeAddress(record.ToAddress), record.Asset)
+			nets[key] = nets[key].Add(amount)
+		}
+	}
+	for _, wallet := range wallets {
+		key := r.lockName(wallet.Address, wallet.Asset)
+		if !nets[key].Equal(decimal.RequireFromString(wallet.Balance)) {
+			return fmt.Errorf("wallet %s: balance does not match its transactions", wallet.Address)
+		}
+		delete(nets, key)
+	}
+	for key, net := range nets {
+		if !net.IsZero() {
+			return fmt.Errorf("wallet %s: balance does not match its transactions", key)
+		}
+	}
+
+	return nil
+}
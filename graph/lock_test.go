@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"context"
+	"testing"
+)
+
+// Replace address hash for the duration of a test
+func forceAddressHash(t *testing.T, hash func(string) int64) {
+	t.Helper()
+	original := hashAddress
+	hashAddress = hash
+	t.Cleanup(func() { hashAddress = original })
+}
+
+func TestLockKeys(t *testing.T) {
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Distinct hashes: two keys in ascending order, whatever the direction
+	keys := lockKeys(aAddress, bAddress)
+	if len(keys) != 2 || keys[0] >= keys[1] {
+		t.Fatalf("Expected two ascending keys, got %v", keys)
+	}
+	if reversed := lockKeys(bAddress, aAddress); reversed[0] != keys[0] || reversed[1] != keys[1] {
+		t.Errorf("Expected same key order for reversed transfer, got %v and %v", keys, reversed)
+	}
+}
+
+func TestLockKeysHashCollision(t *testing.T) {
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+	dAddress := "0xD000000000000000000000000000000000000000"
+
+	// Every address hashes to the same key
+	forceAddressHash(t, func(string) int64 { return 42 })
+
+	// Single lock is taken instead of the same one twice
+	keys := lockKeys(aAddress, bAddress)
+	if len(keys) != 1 || keys[0] != 42 {
+		t.Fatalf("Expected single key 42, got %v", keys)
+	}
+
+	// Colliding transfers wait on each other even without a shared wallet
+	resolver := &Resolver{Debug: true}
+	serialize, err := resolver.Query().WouldSerialize(context.Background(), aAddress, bAddress, cAddress, dAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !serialize {
+		t.Error("Expected colliding transfers to serialize")
+	}
+}
+
+func TestWouldSerializeLockStrategy(t *testing.T) {
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+	dAddress := "0xD000000000000000000000000000000000000000"
+
+	// Every address hashes to the same advisory lock key
+	forceAddressHash(t, func(string) int64 { return 42 })
+
+	cases := []struct {
+		strategy   LockStrategy
+		a, b, c, d string
+		expected   bool
+	}{
+		{LockAdvisory, aAddress, bAddress, cAddress, dAddress, true},
+		{LockRow, aAddress, bAddress, cAddress, dAddress, false},
+		{LockRow, aAddress, bAddress, "0xb000000000000000000000000000000000000000", cAddress, true},
+		{LockOptimistic, aAddress, bAddress, cAddress, dAddress, false},
+		{LockOptimistic, aAddress, bAddress, bAddress, aAddress, true},
+	}
+	for _, c := range cases {
+		// The transaction log does not make disjoint transfers wait
+		resolver := &Resolver{Debug: true, LockStrategy: c.strategy, TransactionTable: "transactions"}
+		serialize, err := resolver.Query().WouldSerialize(context.Background(), c.a, c.b, c.c, c.d)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if serialize != c.expected {
+			t.Errorf("%s: expected %v for %s->%s and %s->%s, got %v", c.strategy, c.expected, c.a, c.b, c.c, c.d, serialize)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
+)
+
+// Number of transfer retries after a write conflict in optimistic mode
+var OptimisticRetries = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "optimistic_retries_total",
+	Help: "Number of transfers retried after a concurrent wallet update in optimistic mode.",
+})
+
+// Number of transfers run again after Postgres aborted them, labeled by reason: serialization_failure or deadlock
+var TransferRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "transfer_retries_total",
+	Help: "Number of transfers retried after a serialization failure or deadlock.",
+}, []string{"reason"})
+
+// Number of requests rejected by input validation, labeled by reason and source: the API key
+// identity, or "anonymous" so client addresses do not each get a series
+var ValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "validation_failures_total",
+	Help: "Number of requests rejected by input validation.",
+}, []string{"reason", "source"})
+
+// Number of transfers rejected because treasury would go negative
+var TreasuryInsufficientBalance = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "treasury_insufficient_balance_total",
+	Help: "Number of transfers rejected because the treasury balance is too low.",
+})
+
+// Number of balances read from DB that do not fit NUMERIC(28,18), labeled by kind
+var BalancePrecisionViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "balance_precision_violations_total",
+	Help: "Number of stored balances that do not fit NUMERIC(28,18).",
+}, []string{"kind"})
+
+// Number of transfers by result: success or failure
+var TransfersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "transfers_total",
+	Help: "Number of transfers by result.",
+}, []string{"result"})
+
+// Number of failed transfers by error category, see transferErrorCategory
+var TransferFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "transfer_failures_total",
+	Help: "Number of failed transfers by error category.",
+}, []string{"category"})
+
+// Transfer latency, including validation and commit
+var TransferDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "transfer_duration_seconds",
+	Help:    "Duration of transfers in seconds.",
+	Buckets: prometheus.DefBuckets,
+})
+
+// Total amount moved by successful transfers; float, so approximate for large totals
+var TransferAmountSum = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "transfer_amount_sum",
+	Help: "Total amount of tokens moved by successful transfers.",
+})
+
+// Record outcome of a transfer
+func observeTransfer(amount string, duration time.Duration, err error) {
+	TransferDuration.Observe(duration.Seconds())
+
+	if err != nil {
+		TransfersTotal.WithLabelValues("failure").Inc()
+		TransferFailures.WithLabelValues(transferErrorCategory(err)).Inc()
+		return
+	}
+
+	TransfersTotal.WithLabelValues("success").Inc()
+	if value, err := decimal.NewFromString(amount); err == nil {
+		TransferAmountSum.Add(value.InexactFloat64())
+	}
+}
+
+// Metric label of transfer error
+func transferErrorCategory(err error) string {
+	var validationErr *validationError
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, ErrInsufficientBalance):
+		return "insufficient_balance"
+	case errors.Is(err, ErrInsufficientAllowance):
+		return "insufficient_allowance"
+	case errors.Is(err, ErrInvalidAddress), errors.Is(err, ErrSameAddress):
+		return "invalid_address"
+	case errors.Is(err, ErrInvalidAmount):
+		return "invalid_amount"
+	case errors.As(err, &validationErr):
+		return "invalid_input"
+	case errors.Is(err, ErrTransferTimeout):
+		return "timeout"
+	case errors.Is(err, ErrRateLimitExceeded):
+		return "rate_limited"
+	case errors.Is(err, ErrWalletFrozen):
+		return "wallet_frozen"
+	case errors.Is(err, ErrWriteConflict):
+		return "conflict"
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	case errors.As(err, &pqErr), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, sql.ErrTxDone), errors.Is(err, driver.ErrBadConn):
+		return "db_error"
+	default:
+		return "rejected"
+	}
+}
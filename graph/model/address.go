@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// "0x" followed by exactly 40 hex characters; checksum case is not enforced
+var addressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// Length of a valid address, checked before the regex so long input is rejected early
+const addressLength = 42
+
+// Check if address has the 0x + 40 hex format
+func ValidAddress(address string) bool {
+	return len(address) == addressLength && addressRegex.MatchString(address)
+}
+
+// Address scalar, bound to string in gqlgen.yml
+func MarshalAddress(address string) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		graphql.MarshalString(address).MarshalGQL(w)
+	})
+}
+
+// Reject malformed addresses while parsing the request, before any resolver runs
+func UnmarshalAddress(v any) (string, error) {
+	address, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("address must be a string, got %T", v)
+	}
+	if !ValidAddress(address) {
+		return "", fmt.Errorf("invalid Ethereum address format")
+	}
+	return address, nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalAddress(t *testing.T) {
+	valid := []string{
+		"0x0000000000000000000000000000000000000000",
+		"0xa000000000000000000000000000000000000000",
+		"0xAbCdEf0123456789aBcDeF0123456789AbCdEf01",
+	}
+	for _, address := range valid {
+		got, err := UnmarshalAddress(address)
+		if err != nil || got != address {
+			t.Errorf("Expected %q to unmarshal unchanged, got %q, %v", address, got, err)
+		}
+	}
+
+	invalid := []any{
+		"",
+		"0x",
+		"0x123",
+		"a000000000000000000000000000000000000000",
+		"0xg000000000000000000000000000000000000000",
+		"0x00000000000000000000000000000000000000000",
+		" 0x0000000000000000000000000000000000000000",
+		"0x0000000000000000000000000000000000000000\n",
+		"0x" + strings.Repeat("0", 100000),
+		42,
+		nil,
+	}
+	for _, v := range invalid {
+		if _, err := UnmarshalAddress(v); err == nil {
+			t.Errorf("Expected %.50v to be rejected", v)
+		}
+	}
+}
+
+func TestMarshalAddress(t *testing.T) {
+	var buf bytes.Buffer
+	MarshalAddress("0xa000000000000000000000000000000000000000").MarshalGQL(&buf)
+	if got := buf.String(); got != `"0xa000000000000000000000000000000000000000"` {
+		t.Errorf("Expected quoted address, got %s", got)
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/shopspring/decimal"
+)
+
+// Limits of NUMERIC(28,18) token amounts
+const (
+	maxDecimalPlaces = 18
+	maxDecimalDigits = 28
+)
+
+// Malformed Decimal; Reason is the validation failure label, e.g. "too_many_decimals"
+type DecimalError struct {
+	Reason  string
+	Message string
+}
+
+func (e *DecimalError) Error() string {
+	return e.Message
+}
+
+// Parse amount in plain decimal notation that fits NUMERIC(28,18); the sign is not checked
+// Errors are *DecimalError
+func ParseDecimal(amount string) (decimal.Decimal, error) {
+	// Only plain decimal notation: no whitespace and no exponent such as "1e3"
+	if strings.IndexFunc(amount, unicode.IsSpace) >= 0 || strings.ContainsAny(amount, "eE") {
+		return decimal.Zero, &DecimalError{"invalid_amount", "invalid decimal amount"}
+	}
+
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Zero, &DecimalError{"invalid_amount", "invalid decimal amount"}
+	}
+
+	if value.Exponent() < -maxDecimalPlaces {
+		return decimal.Zero, &DecimalError{"too_many_decimals", fmt.Sprintf("too many decimal places: max %d allowed", maxDecimalPlaces)}
+	}
+
+	// Check if amount does not have more than 28 digits
+	if len(value.Coefficient().String()) > maxDecimalDigits {
+		return decimal.Zero, &DecimalError{"too_many_digits", fmt.Sprintf("too many digits: max precision is %d", maxDecimalDigits)}
+	}
+	return value, nil
+}
+
+// Decimal scalar, bound to string in gqlgen.yml
+func MarshalDecimal(amount string) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		graphql.MarshalString(amount).MarshalGQL(w)
+	})
+}
+
+// Reject malformed and negative amounts while parsing the request, before any resolver runs
+// Amounts must be strings: number literals would lose precision as float64
+func UnmarshalDecimal(v any) (string, error) {
+	amount, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("decimal must be a string, got %T", v)
+	}
+
+	value, err := ParseDecimal(amount)
+	if err != nil {
+		return "", err
+	}
+	if value.IsNegative() {
+		return "", errors.New("amount must not be negative")
+	}
+	return amount, nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalDecimal(t *testing.T) {
+	valid := []string{"0", "1", "145.678900", "0.000000000000000001", "9999999999.999999999999999999"}
+	for _, amount := range valid {
+		got, err := UnmarshalDecimal(amount)
+		if err != nil || got != amount {
+			t.Errorf("Expected %q to unmarshal unchanged, got %q, %v", amount, got, err)
+		}
+	}
+
+	invalid := []struct {
+		value   any
+		message string
+	}{
+		{"abc", "invalid decimal amount"},
+		{"", "invalid decimal amount"},
+		{"1e3", "invalid decimal amount"},
+		{" 5", "invalid decimal amount"},
+		{"-1", "amount must not be negative"},
+		{"0.0000000000000000001", "too many decimal places: max 18 allowed"},
+		{"99999999999.999999999999999999", "too many digits: max precision is 28"},
+		{1.5, "decimal must be a string, got float64"},
+		{int64(1), "decimal must be a string, got int64"},
+	}
+	for _, c := range invalid {
+		if _, err := UnmarshalDecimal(c.value); err == nil || err.Error() != c.message {
+			t.Errorf("Expected %q for %v, got: %v", c.message, c.value, err)
+		}
+	}
+}
+
+func TestParseDecimalReason(t *testing.T) {
+	cases := map[string]string{
+		"1,5":                           "invalid_amount",
+		"0.0000000000000000001":         "too_many_decimals",
+		"10000000000000000000000000000": "too_many_digits",
+	}
+	for amount, reason := range cases {
+		_, err := ParseDecimal(amount)
+		var decimalErr *DecimalError
+		if !errors.As(err, &decimalErr) || decimalErr.Reason != reason {
+			t.Errorf("Expected reason %q for %q, got: %v", reason, amount, err)
+		}
+	}
+
+	// Sign is left to the caller
+	if value, err := ParseDecimal("-1.5"); err != nil || value.String() != "-1.5" {
+		t.Errorf("Expected -1.5, got %v, %v", value, err)
+	}
+}
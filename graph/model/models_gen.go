@@ -2,13 +2,186 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type Allowance struct {
+	OwnerAddress   string `json:"owner_address"`
+	SpenderAddress string `json:"spender_address"`
+	Amount         string `json:"amount"`
+}
+
+type Balance struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+	Units   string `json:"units"`
+}
+
+type BalanceChange struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+}
+
+type ChainVerification struct {
+	Valid    bool    `json:"valid"`
+	Checked  int32   `json:"checked"`
+	BrokenAt *string `json:"broken_at,omitempty"`
+}
+
+type FlowEdge struct {
+	FromAddress string `json:"from_address"`
+	ToAddress   string `json:"to_address"`
+	Volume      string `json:"volume"`
+	Transfers   int32  `json:"transfers"`
+}
+
+type LedgerSummary struct {
+	Wallets      int32  `json:"wallets"`
+	Transactions int32  `json:"transactions"`
+	Supply       string `json:"supply"`
+}
+
 type Mutation struct {
 }
 
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
 type Query struct {
 }
 
+type RateStats struct {
+	Window    string `json:"window"`
+	Transfers int32  `json:"transfers"`
+	Volume    string `json:"volume"`
+}
+
+type Subscription struct {
+}
+
+type Transaction struct {
+	ID          string    `json:"id"`
+	FromAddress string    `json:"from_address"`
+	ToAddress   string    `json:"to_address"`
+	Asset       string    `json:"asset"`
+	Amount      string    `json:"amount"`
+	Timestamp   time.Time `json:"timestamp"`
+	Hash        string    `json:"hash"`
+	Memo        *string   `json:"memo,omitempty"`
+}
+
+type TransactionConnection struct {
+	Edges    []*TransactionEdge `json:"edges"`
+	PageInfo *PageInfo          `json:"pageInfo"`
+}
+
+type TransactionEdge struct {
+	Node   *Transaction `json:"node"`
+	Cursor string       `json:"cursor"`
+}
+
+type TransferResult struct {
+	FromAddress      string    `json:"from_address"`
+	ToAddress        string    `json:"to_address"`
+	Asset            string    `json:"asset"`
+	SenderBalance    string    `json:"sender_balance"`
+	RecipientBalance string    `json:"recipient_balance"`
+	Amount           string    `json:"amount"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
 type Wallet struct {
+	Address          string  `json:"address"`
+	Asset            string  `json:"asset"`
+	Balance          string  `json:"balance"`
+	OwnerID          *string `json:"owner_id,omitempty"`
+	Frozen           bool    `json:"frozen"`
+	BalanceFormatted string  `json:"balanceFormatted"`
+}
+
+type WalletBalance struct {
 	Address string `json:"address"`
 	Balance string `json:"balance"`
+	Found   bool   `json:"found"`
+}
+
+type WalletConnection struct {
+	Edges    []*WalletEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type WalletEdge struct {
+	Node   *Wallet `json:"node"`
+	Cursor string  `json:"cursor"`
+}
+
+type WalletReconciliation struct {
+	Address         string `json:"address"`
+	StoredBalance   string `json:"stored_balance"`
+	ComputedBalance string `json:"computed_balance"`
+	Consistent      bool   `json:"consistent"`
+}
+
+type TransferDirection string
+
+const (
+	TransferDirectionSent     TransferDirection = "SENT"
+	TransferDirectionReceived TransferDirection = "RECEIVED"
+	TransferDirectionAll      TransferDirection = "ALL"
+)
+
+var AllTransferDirection = []TransferDirection{
+	TransferDirectionSent,
+	TransferDirectionReceived,
+	TransferDirectionAll,
+}
+
+func (e TransferDirection) IsValid() bool {
+	switch e {
+	case TransferDirectionSent, TransferDirectionReceived, TransferDirectionAll:
+		return true
+	}
+	return false
+}
+
+func (e TransferDirection) String() string {
+	return string(e)
+}
+
+func (e *TransferDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TransferDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TransferDirection", str)
+	}
+	return nil
+}
+
+func (e TransferDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TransferDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TransferDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
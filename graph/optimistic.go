@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Attempts of a transfer in optimistic mode when OptimisticAttempts is not set
+const defaultOptimisticAttempts = 3
+
+// Versions read together with the wallets of a transfer
+// In optimistic mode the updates succeed only if the rows still have these versions
+type transferWallets struct {
+	senderBalance    string
+	senderVersion    int64
+	recipientExists  bool
+	recipientVersion int64 // 0 for a wallet created by the transfer
+}
+
+// Run fn in a new DB transaction and commit it
+func (r *Resolver) transferTxAttempt(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: r.IsolationLevel})
+	if err != nil {
+		return err
+	}
+	defer r.rollback(tx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Version of a wallet for the versionGuard of its next update
+// Other lock strategies do not check versions, so the wallet is not read and 0 is returned
+func (r *Resolver) walletVersion(ctx context.Context, tx *sql.Tx, address, asset string) (int64, error) {
+	if r.LockStrategy != LockOptimistic {
+		return 0, nil
+	}
+	var version int64
+	t := r.tables()
+	query := fmt.Sprintf("SELECT version FROM %s WHERE %s = $1 AND asset = $2", t.Wallets, t.AddressCol)
+	err := tx.QueryRowContext(ctx, query, address, asset).Scan(&version)
+	return version, err
+}
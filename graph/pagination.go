@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"token_transfer/graph/model"
+)
+
+// Page size of wallets query
+const (
+	defaultWalletPage = 10
+	maxWalletPage     = 100
+)
+
+// Cursor is the base64-encoded address of the last wallet on the page
+// Clients must treat it as opaque
+func encodeCursor(address string) string {
+	return base64.StdEncoding.EncodeToString([]byte(address))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	address, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor")
+	}
+	return string(address), nil
+}
+
+// Return up to first base asset wallets with address greater than the one in after cursor
+func (r *Resolver) listWallets(ctx context.Context, first *int32, after *string) (*model.WalletConnection, error) {
+	limit := int32(defaultWalletPage)
+	if first != nil {
+		limit = *first
+	}
+	if limit <= 0 || limit > maxWalletPage {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxWalletPage)
+	}
+
+	afterAddress := ""
+	if after != nil {
+		var err error
+		if afterAddress, err = decodeCursor(*after); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra row to know if there is a next page
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %[1]s FROM %[2]s WHERE %[3]s > $1 AND asset = $3 ORDER BY %[3]s LIMIT $2", r.walletColumns(), t.Wallets, t.AddressCol)
+	rows, err := r.DB.QueryContext(ctx, query, afterAddress, limit+1, r.baseAsset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connection := &model.WalletConnection{
+		Edges:    []*model.WalletEdge{},
+		PageInfo: &model.PageInfo{},
+	}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		if int32(len(connection.Edges)) == limit {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+		connection.Edges = append(connection.Edges, &model.WalletEdge{
+			Node:   wallet,
+			Cursor: encodeCursor(wallet.Address),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if count := len(connection.Edges); count > 0 {
+		endCursor := connection.Edges[count-1].Cursor
+		connection.PageInfo.EndCursor = &endCursor
+	}
+	return connection, nil
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Bounds of NUMERIC(28,18) used for balances
+const (
+	balanceScale     = 18
+	balancePrecision = 28
+)
+
+// Integer number of 10^-18 base units of a balance; value must have at most 18 decimals
+func balanceUnits(value decimal.Decimal) string {
+	return value.Shift(balanceScale).String()
+}
+
+// Check balance read from DB against NUMERIC(28,18)
+// Values breaking it indicate corruption; they are logged and, when
+// RepairBalancePrecision is set, excess decimals are truncated
+func (r *Resolver) checkStoredBalance(address, balance string) (string, error) {
+	value, err := decimal.NewFromString(balance)
+	if err != nil {
+		r.logger().Error("stored balance is not a decimal", "address", address, "balance", balance)
+		return "", fmt.Errorf("corrupted balance for %s", address)
+	}
+
+	integerDigits := len(value.Truncate(0).Abs().String())
+	if value.IsNegative() || integerDigits > balancePrecision-balanceScale {
+		r.logger().Error("stored balance out of range", "address", address, "balance", balance)
+		BalancePrecisionViolations.WithLabelValues("out_of_range").Inc()
+		return "", fmt.Errorf("corrupted balance for %s", address)
+	}
+
+	if value.Exponent() < -balanceScale && !value.Equal(value.Truncate(balanceScale)) {
+		BalancePrecisionViolations.WithLabelValues("too_many_decimals").Inc()
+		if !r.RepairBalancePrecision {
+			r.logger().Error("stored balance has too many decimals", "address", address, "balance", balance)
+			return "", fmt.Errorf("corrupted balance for %s", address)
+		}
+
+		repaired := value.Truncate(balanceScale).StringFixed(balanceScale)
+		r.logger().Warn("stored balance precision repaired", "address", address, "balance", balance, "repaired", repaired)
+		return repaired, nil
+	}
+
+	return balance, nil
+}
+
+// Check new balance still fits NUMERIC(28,18), so the update cannot overflow the column
+func checkBalanceFits(balance decimal.Decimal) error {
+	if len(balance.Truncate(0).Abs().String()) > balancePrecision-balanceScale {
+		return fmt.Errorf("balance would exceed maximum of NUMERIC(%d,%d)", balancePrecision, balanceScale)
+	}
+	return nil
+}
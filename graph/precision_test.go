@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+)
+
+// Values are passed as if read from token_balance, so corrupted rows can be
+// simulated without a DB that would reject them
+func TestCheckStoredBalance(t *testing.T) {
+	resolver := &Resolver{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	address := "0xA000000000000000000000000000000000000000"
+
+	// Valid balance is returned unchanged
+	balance, err := resolver.checkStoredBalance(address, "1000.000000000000000000")
+	if err != nil {
+		t.Fatalf("Valid balance rejected: %v", err)
+	}
+	if balance != "1000.000000000000000000" {
+		t.Errorf("Expected balance unchanged, got %s", balance)
+	}
+
+	// Out of range values are always detected
+	outOfRange := []string{
+		"10000000000.000000000000000000",
+		"-1.000000000000000000",
+		"not-a-number",
+	}
+	before := testutil.ToFloat64(BalancePrecisionViolations.WithLabelValues("out_of_range"))
+	for _, value := range outOfRange {
+		if _, err := resolver.checkStoredBalance(address, value); err == nil {
+			t.Errorf("Out of range balance %s was not detected", value)
+		}
+	}
+	if got := testutil.ToFloat64(BalancePrecisionViolations.WithLabelValues("out_of_range")) - before; got != 2 {
+		t.Errorf("Expected 2 out_of_range violations, got %v", got)
+	}
+
+	// Too many decimals are rejected unless repair is enabled
+	tooPrecise := "1.0000000000000000019"
+	if _, err := resolver.checkStoredBalance(address, tooPrecise); err == nil {
+		t.Error("Balance with too many decimals was not detected")
+	}
+
+	resolver.RepairBalancePrecision = true
+	balance, err = resolver.checkStoredBalance(address, tooPrecise)
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if balance != "1.000000000000000001" {
+		t.Errorf("Expected repaired balance 1.000000000000000001, got %s", balance)
+	}
+}
+
+func TestBalanceUnits(t *testing.T) {
+	cases := []struct {
+		stored string
+		units  string
+	}{
+		{"1000.000000000000000000", "1000000000000000000000"},
+		{"0.000000000000000001", "1"},
+		{"1.5", "1500000000000000000"},
+		{"0", "0"},
+		{"0.000000000000000000", "0"},
+		{"9999999999.999999999999999999", "9999999999999999999999999999"},
+	}
+	for _, c := range cases {
+		units := balanceUnits(decimal.RequireFromString(c.stored))
+		if units != c.units {
+			t.Errorf("Expected %s to be %s units, got %s", c.stored, c.units, units)
+		}
+	}
+}
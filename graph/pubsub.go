@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"sync"
+
+	"token_transfer/graph/model"
+)
+
+// Buffered updates per subscriber; updates for a slower subscriber are dropped
+const balanceSubscriberBuffer = 16
+
+// In-process pub/sub of balance changes, keyed by wallet address
+// Only transfers committed by this process are published
+type balanceBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *model.BalanceChange]struct{}
+}
+
+// Register subscriber for address; unsubscribe closes the returned channel
+func (b *balanceBroker) subscribe(address string) (<-chan *model.BalanceChange, func()) {
+	ch := make(chan *model.BalanceChange, balanceSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers == nil {
+		b.subscribers = map[string]map[chan *model.BalanceChange]struct{}{}
+	}
+	if b.subscribers[address] == nil {
+		b.subscribers[address] = map[chan *model.BalanceChange]struct{}{}
+	}
+	b.subscribers[address][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[address], ch)
+			if len(b.subscribers[address]) == 0 {
+				delete(b.subscribers, address)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Send new balance to every subscriber of address without blocking the caller
+func (b *balanceBroker) publish(address, balance string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[address] {
+		select {
+		case ch <- &model.BalanceChange{Address: address, Balance: balance}:
+		default:
+		}
+	}
+}
+
+// Publish new balances of both wallets touched by a committed transfer
+func (r *Resolver) publishBalances(fromAddress, toAddress string, result transferResult) {
+	if result.asset != r.baseAsset() {
+		return
+	}
+	r.balances.publish(fromAddress, result.senderBalance)
+	r.balances.publish(toAddress, result.recipientBalance)
+}
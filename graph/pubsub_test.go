@@ -0,0 +1,44 @@
+package graph
+
+import "testing"
+
+func TestBalanceBroker(t *testing.T) {
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	var broker balanceBroker
+	updates, unsubscribe := broker.subscribe(aAddress)
+
+	// Only updates for the subscribed address are delivered
+	broker.publish(bAddress, "1.000000000000000000")
+	broker.publish(aAddress, "2.000000000000000000")
+
+	select {
+	case update := <-updates:
+		if update.Address != aAddress || update.Balance != "2.000000000000000000" {
+			t.Errorf("Expected update of %s to 2, got %+v", aAddress, update)
+		}
+	default:
+		t.Fatal("Expected update for subscribed address")
+	}
+	select {
+	case update := <-updates:
+		t.Fatalf("Expected no more updates, got %+v", update)
+	default:
+	}
+
+	// Slow subscriber does not block publishers
+	for i := 0; i < balanceSubscriberBuffer+1; i++ {
+		broker.publish(aAddress, "3.000000000000000000")
+	}
+
+	// Unsubscribe closes the channel and may be called again
+	unsubscribe()
+	unsubscribe()
+	for range updates {
+	}
+	if _, ok := broker.subscribers[aAddress]; ok {
+		t.Error("Expected no subscribers left after unsubscribe")
+	}
+	broker.publish(aAddress, "4.000000000000000000")
+}
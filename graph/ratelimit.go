@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiters idle for longer than this have refilled their bucket and can be dropped
+const rateLimiterIdle = time.Minute
+
+// Token bucket per sender address: up to perMinute transfers in a burst,
+// refilled at perMinute tokens per minute
+type senderLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*senderLimiterEntry
+	lastSweep time.Time
+}
+
+type senderLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Take one token from the bucket of address; false when it is empty
+func (l *senderLimiter) allow(address string, perMinute int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limiters == nil {
+		l.limiters = map[string]*senderLimiterEntry{}
+	}
+
+	// Evict idle entries at most once per idle period, so the map does not grow with every sender ever seen
+	if now.Sub(l.lastSweep) >= rateLimiterIdle {
+		for key, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) >= rateLimiterIdle {
+				delete(l.limiters, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.limiters[address]
+	if !ok {
+		entry = &senderLimiterEntry{limiter: rate.NewLimiter(rate.Limit(float64(perMinute)/60), perMinute)}
+		l.limiters[address] = entry
+	}
+	entry.lastSeen = now
+
+	return entry.limiter.AllowN(now, 1)
+}
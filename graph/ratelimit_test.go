@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSenderLimiter(t *testing.T) {
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	var limiter senderLimiter
+	now := time.Now()
+
+	// Burst of perMinute transfers, then the bucket is empty
+	for i := 0; i < 3; i++ {
+		if !limiter.allow(aAddress, 3, now) {
+			t.Fatalf("Transfer %d within limit was rejected", i+1)
+		}
+	}
+	if limiter.allow(aAddress, 3, now) {
+		t.Error("Transfer over limit was allowed")
+	}
+
+	// Other senders have their own bucket
+	if !limiter.allow(bAddress, 3, now) {
+		t.Error("Transfer from other sender was rejected")
+	}
+
+	// One token is refilled every 20 seconds
+	if !limiter.allow(aAddress, 3, now.Add(20*time.Second)) {
+		t.Error("Transfer after refill was rejected")
+	}
+
+	// Idle senders are evicted
+	limiter.allow(bAddress, 3, now.Add(2*rateLimiterIdle))
+	if _, ok := limiter.limiters[aAddress]; ok {
+		t.Error("Idle sender was not evicted")
+	}
+}
@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Executable schema with queries only, for observers that must not move tokens
+// Mutation type is not present, so every mutation is rejected during validation
+func NewReadOnlyExecutableSchema(cfg Config) graphql.ExecutableSchema {
+	cfg.Schema = readOnlySchema()
+	return NewExecutableSchema(cfg)
+}
+
+// Copy of parsed schema without the Mutation type
+func readOnlySchema() *ast.Schema {
+	full := parsedSchema
+
+	schema := *full
+	schema.Mutation = nil
+	schema.Types = make(map[string]*ast.Definition, len(full.Types))
+	for name, definition := range full.Types {
+		if definition == full.Mutation {
+			continue
+		}
+		schema.Types[name] = definition
+	}
+
+	return &schema
+}
@@ -1,9 +1,202 @@
 package graph
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // Dependency injection for the app.
+// Call Validate after constructing it: configured table and column names are put into SQL unquoted
 type Resolver struct {
-	DB          *sql.DB
-	WalletTable string // name of DB table
+	DB               *sql.DB
+	ReadDB           *sql.DB     // optional read replica for wallet and totalSupply; DB when nil
+	WalletTable      string      // name of DB table
+	Tables           TableConfig // wallet table and column names; overrides WalletTable when Tables.Wallets is set
+	BaseAsset        string      // asset of operations without an asset argument; "TOKEN" when not set
+	TransactionTable string      // name of DB table with transfer history; history is not recorded when empty
+
+	TreasuryAddress   string // wallet holding the initial token supply
+	TreasuryProtected bool   // funds leave treasury only through treasuryTransfer
+	TreasurySendOnly  bool   // treasury never receives tokens: transfers and mints to it are rejected
+	AuditTable        string // name of DB table with treasury audit entries
+	AllowanceTable    string // name of DB table with allowances; approve and transferFrom are disabled when empty
+
+	MintEnabled bool // allow mint, which increases total supply
+
+	LedgerImportEnabled bool // allow importLedger; meant for restoring a backup into an empty DB
+	LedgerExportEnabled bool // allow exportLedger, which returns every wallet and transaction
+
+	RepairBalancePrecision bool // truncate stored balances with more than 18 decimals instead of failing
+
+	LockStrategy       LockStrategy       // how transfers lock wallets; advisory locks when not set
+	OptimisticAttempts int                // attempts of a transfer with LockOptimistic; 3 when not set
+	TransferRetries    int                // retries of a transfer aborted with a serialization failure or deadlock; 0 disables retries
+	IsolationLevel     sql.IsolationLevel // isolation of transfer transactions; Postgres default (read committed) when not set
+	TransferTimeout    time.Duration      // max duration of a transfer, including lock waits; 0 disables the limit
+
+	AllowOwnerRelink bool // allow moving a linked wallet to another owner
+
+	AutoCreateSender     bool            // create missing sender wallet instead of rejecting transfer
+	DefaultSenderBalance decimal.Decimal // starting balance of auto-created sender wallet
+
+	NewWalletMinAmount decimal.Decimal // min amount of a transfer creating recipient wallet; 0 disables the check
+	MinTransferAmount  decimal.Decimal // min amount of a single transfer; 0 allows any positive amount
+	MaxTransferAmount  decimal.Decimal // max amount of a single transfer; 0 means unlimited
+
+	DecimalSeparator string // decimal separator in formatted balances; "." when not set
+	GroupSeparator   string // thousands separator in formatted balances; "," when not set
+
+	Debug bool // enable debug-only queries
+
+	// Micro-batching: transfers arriving within BatchWindow are committed in one DB transaction
+	// Each transfer runs in its own savepoint, so it still succeeds or fails on its own
+	BatchWindow  time.Duration // 0 disables batching
+	BatchMaxSize int           // max transfers per batch; 100 when not set
+	batcher      transferBatcher
+
+	// Max transfers per sender address per minute, as a token bucket; 0 disables the limit
+	TransferRateLimit int
+	rateLimiter       senderLimiter
+
+	// Subscribers of balanceChanged, notified after each committed transfer
+	balances balanceBroker
+
+	// Optional http(s) URL notified with a JSON POST after each committed transfer
+	// Delivery is asynchronous with retries; failures are only logged
+	TransferWebhookURL string
+	webhook            webhookNotifier
+
+	// Hot transfer queries prepared by Prepare; SQL is sent inline when not prepared
+	statements preparedStatements
+
+	// Keep no state on DB sessions, for PgBouncer in transaction pooling mode: Prepare does nothing
+	// Locks and settings of the transfer path are transaction-scoped either way
+	PoolerCompatible bool
+
+	Logger                *slog.Logger // structured logger; slog.Default() when nil
+	AuditLogger           *slog.Logger // audit log with a line per transfer; Logger when nil
+	LogValidationFailures bool         // log every request rejected by validation
+	LogLockOrder          bool         // log advisory lock keys in the order they are acquired
+}
+
+// Return configured logger or the default one
+func (r *Resolver) logger() *slog.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return slog.Default()
+}
+
+// Return configured audit logger, falling back to the general one
+func (r *Resolver) auditLogger() *slog.Logger {
+	if r.AuditLogger != nil {
+		return r.AuditLogger
+	}
+	return r.logger()
+}
+
+// Roll back tx, meant to be deferred; a rollback after commit returns ErrTxDone and is not logged
+// Any other error means the rollback itself failed, e.g. on a dead connection, and is not otherwise visible
+func (r *Resolver) rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		r.logger().Error("transaction rollback failed", "error", err)
+	}
+}
+
+// How a transfer locks its two wallets
+type LockStrategy string
+
+const (
+	// Advisory locks on FNV-64 hashes of addresses; also covers wallets that do not exist yet
+	LockAdvisory LockStrategy = "advisory"
+	// SELECT ... FOR UPDATE on wallet rows; no hash collisions, but missing wallets are not locked
+	LockRow LockStrategy = "row"
+	// No locks while reading; updates check the wallet version and the transfer is retried on conflict
+	LockOptimistic LockStrategy = "optimistic"
+)
+
+// Table names are put into queries with fmt.Sprintf, as identifiers cannot be parameterized
+// DB for queries that may read slightly stale data: the replica when configured, the primary otherwise
+func (r *Resolver) readDB() *sql.DB {
+	if r.ReadDB != nil {
+		return r.ReadDB
+	}
+	return r.DB
+}
+
+// Plain unquoted SQL identifier of at most 63 characters, the Postgres limit
+var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,62}$`)
+
+// Check a table or column name from config before it is put into SQL as is
+// Names cannot be passed as query parameters, so anything but a plain identifier is rejected
+func validateIdentifier(name string) error {
+	if !identifierRegex.MatchString(name) {
+		return fmt.Errorf("invalid SQL identifier %q", name)
+	}
+	return nil
+}
+
+// Check configured table and column names are plain SQL identifiers, base asset is a valid code, lock strategy and isolation level are known and webhook URL is http(s)
+// Empty optional tables (TransactionTable, AuditTable, AllowanceTable) disable their features and are accepted
+func (r *Resolver) Validate() error {
+	if err := r.tables().validate(); err != nil {
+		return err
+	}
+	if r.BaseAsset != "" && validateAsset(r.BaseAsset) != nil {
+		return fmt.Errorf("invalid base asset %q", r.BaseAsset)
+	}
+	switch r.LockStrategy {
+	case "", LockAdvisory, LockRow, LockOptimistic:
+	default:
+		return fmt.Errorf("invalid lock strategy %q", r.LockStrategy)
+	}
+	for _, table := range []string{r.TransactionTable, r.AuditTable, r.AllowanceTable} {
+		if table == "" {
+			continue
+		}
+		if err := validateIdentifier(table); err != nil {
+			return fmt.Errorf("invalid table name: %w", err)
+		}
+	}
+	if r.TransactionTable != "" {
+		if err := validateIdentifier(r.chainHeadTable()); err != nil {
+			return fmt.Errorf("invalid table name: %w", err)
+		}
+	}
+	// Batched transfers share one DB transaction, which cannot be retried for a single transfer
+	if r.LockStrategy == LockOptimistic && r.BatchWindow > 0 {
+		return fmt.Errorf("optimistic lock strategy cannot be combined with batching")
+	}
+	switch r.IsolationLevel {
+	case sql.LevelDefault, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+	default:
+		return fmt.Errorf("unsupported isolation level %s", r.IsolationLevel)
+	}
+	// Batches stay at read committed: a serialization failure would abort every transfer in the batch
+	if (r.IsolationLevel == sql.LevelRepeatableRead || r.IsolationLevel == sql.LevelSerializable) && r.BatchWindow > 0 {
+		return fmt.Errorf("isolation level %s cannot be combined with batching", r.IsolationLevel)
+	}
+	if r.OptimisticAttempts < 0 {
+		return fmt.Errorf("invalid optimistic attempts %d", r.OptimisticAttempts)
+	}
+	if r.TransferRetries < 0 {
+		return fmt.Errorf("invalid transfer retries %d", r.TransferRetries)
+	}
+	if r.MaxTransferAmount.IsPositive() && r.MinTransferAmount.GreaterThan(r.MaxTransferAmount) {
+		return fmt.Errorf("min transfer amount %s is greater than max %s", r.MinTransferAmount, r.MaxTransferAmount)
+	}
+	if r.TransferWebhookURL != "" {
+		webhookURL, err := url.Parse(r.TransferWebhookURL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return fmt.Errorf("invalid transfer webhook URL %q", r.TransferWebhookURL)
+		}
+	}
+	return nil
 }
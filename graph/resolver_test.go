@@ -0,0 +1,301 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func TestResolverValidate(t *testing.T) {
+	valid := []*Resolver{
+		{WalletTable: "wallets", TransactionTable: "transactions", AuditTable: "treasury_audit"},
+		{WalletTable: "test_wallets"},
+		{WalletTable: "_Wallets2"},
+		{WalletTable: "wallets", LockStrategy: LockRow},
+		{WalletTable: "wallets", LockStrategy: LockOptimistic, OptimisticAttempts: 5},
+		{WalletTable: "wallets", IsolationLevel: sql.LevelSerializable},
+		{WalletTable: "wallets", IsolationLevel: sql.LevelReadCommitted, BatchWindow: time.Millisecond},
+		{WalletTable: "wallets", MinTransferAmount: decimal.RequireFromString("1"), MaxTransferAmount: decimal.RequireFromString("1")},
+		{WalletTable: "wallets", MinTransferAmount: decimal.RequireFromString("1")},
+		{WalletTable: "wallets", TransferWebhookURL: "https://example.com/hooks/transfer"},
+		{WalletTable: "wallets", BaseAsset: "USDC"},
+		{Tables: TableConfig{Wallets: "accounts", AddressCol: "account_address", BalanceCol: "amount"}},
+		{WalletTable: "wallets", Tables: TableConfig{BalanceCol: "balance"}},
+	}
+	for _, resolver := range valid {
+		if err := resolver.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got: %v", resolver, err)
+		}
+	}
+
+	invalid := []*Resolver{
+		{},
+		{WalletTable: "wallets; DROP TABLE wallets"},
+		{WalletTable: "1wallets"},
+		{WalletTable: "public.wallets"},
+		{WalletTable: "wallets", TransactionTable: "transactions--"},
+		{WalletTable: "wallets", TransactionTable: strings.Repeat("t", 60)}, // chain head table name too long
+		{WalletTable: "wallets", AuditTable: `"audit"`},
+		{WalletTable: "wallets", LockStrategy: "pessimistic"},
+		{WalletTable: "wallets", LockStrategy: LockOptimistic, BatchWindow: time.Millisecond},
+		{WalletTable: "wallets", OptimisticAttempts: -1},
+		{WalletTable: "wallets", TransferRetries: -1},
+		{WalletTable: "wallets", IsolationLevel: sql.LevelReadUncommitted},
+		{WalletTable: "wallets", IsolationLevel: sql.LevelLinearizable},
+		{WalletTable: "wallets", IsolationLevel: sql.LevelSerializable, BatchWindow: time.Millisecond},
+		{WalletTable: "wallets", MinTransferAmount: decimal.RequireFromString("2"), MaxTransferAmount: decimal.RequireFromString("1")},
+		{WalletTable: "wallets", TransferWebhookURL: "example.com/hook"},
+		{WalletTable: "wallets", TransferWebhookURL: "ftp://example.com/hook"},
+		{WalletTable: "wallets", BaseAsset: "usdc"},
+		{Tables: TableConfig{AddressCol: "address"}},
+		{WalletTable: "wallets", Tables: TableConfig{AddressCol: "address; DROP TABLE wallets"}},
+		{WalletTable: "wallets", Tables: TableConfig{BalanceCol: "token-balance"}},
+		{WalletTable: "wallets", Tables: TableConfig{Wallets: "public.accounts"}},
+	}
+	for _, resolver := range invalid {
+		if err := resolver.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", resolver)
+		}
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, name := range []string{"wallets", "test_wallets", "_Wallets2", "W", strings.Repeat("a", 63)} {
+		if err := validateIdentifier(name); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", name, err)
+		}
+	}
+
+	// Injection attempts and names Postgres would quote, truncate or resolve elsewhere
+	invalid := []string{
+		"",
+		"wallets; DROP TABLE wallets",
+		"wallets; DROP TABLE",
+		"wallets--",
+		"wallets/**/",
+		"wallets WHERE 1=1",
+		"wallets)",
+		`"wallets"`,
+		"'wallets'",
+		"public.wallets",
+		"wallets ",
+		"wallets\n",
+		"wallets\x00",
+		"1wallets",
+		"wallèts",
+		strings.Repeat("a", 64),
+	}
+	for _, name := range invalid {
+		if err := validateIdentifier(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+
+	// Every configurable name goes through it
+	for _, resolver := range []*Resolver{
+		{WalletTable: "wallets; DROP TABLE wallets"},
+		{WalletTable: strings.Repeat("w", 64)},
+		{WalletTable: "wallets", TransactionTable: "transactions; DROP TABLE"},
+		{WalletTable: "wallets", AuditTable: "audit--"},
+		{WalletTable: "wallets", AllowanceTable: "allowances)"},
+		{WalletTable: "wallets", Tables: TableConfig{Wallets: "accounts; DROP TABLE accounts"}},
+		{WalletTable: "wallets", Tables: TableConfig{AddressCol: "address = address OR 1=1 --"}},
+		{WalletTable: "wallets", Tables: TableConfig{BalanceCol: "token_balance, (SELECT 1)"}},
+	} {
+		if err := resolver.Validate(); err == nil || !strings.Contains(err.Error(), "invalid SQL identifier") {
+			t.Errorf("Expected invalid SQL identifier error for %+v, got: %v", resolver, err)
+		}
+	}
+}
+
+func TestValidationErrorSentinels(t *testing.T) {
+	if err := validateEthereumAddress("0x123"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got: %v", err)
+	}
+	if err := validateDifferentAddresses("0xA000000000000000000000000000000000000000", "0xa000000000000000000000000000000000000000"); !errors.Is(err, ErrSameAddress) {
+		t.Errorf("Expected ErrSameAddress, got: %v", err)
+	}
+	for _, amount := range []string{"abc", "0", "-1", "0.0000000000000000001", "12345678901234567890123456789"} {
+		if err := validateTokenAmount(amount); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Expected ErrInvalidAmount for %s, got: %v", amount, err)
+		}
+	}
+
+	// Wrapped validation error keeps its message
+	err := validateTransferInput("0xA000000000000000000000000000000000000000", "0x123", "1")
+	if !errors.Is(err, ErrInvalidAddress) || !strings.Contains(err.Error(), "invalid Ethereum address format") {
+		t.Errorf("Expected wrapped ErrInvalidAddress with original message, got: %v", err)
+	}
+
+	// Reasons without sentinel do not match any
+	if err := checkExpectedBalance("1", "x"); errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected no sentinel for invalid expected balance, got: %v", err)
+	}
+}
+
+func TestTransferErrorCategory(t *testing.T) {
+	cases := []struct {
+		err      error
+		category string
+	}{
+		{ErrInsufficientBalance, "insufficient_balance"},
+		{fmt.Errorf("treasury %w", ErrInsufficientBalance), "insufficient_balance"},
+		{fmt.Errorf("%w: x", ErrInsufficientAllowance), "insufficient_allowance"},
+		{validateEthereumAddress("0x123"), "invalid_address"},
+		{validateDifferentAddresses("0xA", "0xa"), "invalid_address"},
+		{validateTokenAmount("0"), "invalid_amount"},
+		{validateOwnerID(""), "invalid_input"},
+		{sql.ErrNoRows, "not_found"},
+		{&notFoundError{"wallet not found: 0xa"}, "not_found"},
+		{&pq.Error{Code: "40P01"}, "db_error"},
+		{ErrRateLimitExceeded, "rate_limited"},
+		{fmt.Errorf("%w: 0xa", ErrWalletFrozen), "wallet_frozen"},
+		{ErrWriteConflict, "conflict"},
+		{fmt.Errorf("%w: %w", ErrWriteConflict, &pq.Error{Code: "40001"}), "conflict"},
+		{errors.New("transfers from treasury require treasuryTransfer"), "rejected"},
+	}
+
+	for _, c := range cases {
+		if got := transferErrorCategory(c.err); got != c.category {
+			t.Errorf("transferErrorCategory(%v) = %s, expected %s", c.err, got, c.category)
+		}
+	}
+}
+
+func TestTransferTimeoutError(t *testing.T) {
+	// Postgres lock_timeout
+	err := transferTimeoutError(context.Background(), &pq.Error{Code: "55P03"})
+	if !errors.Is(err, ErrTransferTimeout) || !strings.Contains(err.Error(), "transfer timed out") {
+		t.Errorf("Expected ErrTransferTimeout for lock_timeout, got: %v", err)
+	}
+	if category := transferErrorCategory(err); category != "timeout" {
+		t.Errorf("Expected timeout category, got %s", category)
+	}
+
+	// Expired deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if err := transferTimeoutError(ctx, context.DeadlineExceeded); !errors.Is(err, ErrTransferTimeout) {
+		t.Errorf("Expected ErrTransferTimeout for expired deadline, got: %v", err)
+	}
+
+	// Other errors pass through
+	if err := transferTimeoutError(context.Background(), ErrInsufficientBalance); err != ErrInsufficientBalance {
+		t.Errorf("Expected error unchanged, got: %v", err)
+	}
+}
+
+func TestValidateTokenAmountStrictFormat(t *testing.T) {
+	for _, amount := range []string{"1e3", "1E3", "1.5e-2", " 5", "5 ", "5\n", "\t5"} {
+		err := validateTokenAmount(amount)
+		if err == nil || err.Error() != "invalid decimal amount" {
+			t.Errorf("Expected 'invalid decimal amount' for %q, got: %v", amount, err)
+		}
+	}
+
+	// Plain decimal notation is still accepted
+	for _, amount := range []string{"5", "1000", "0.000000000000000001", "145.678900"} {
+		if err := validateTokenAmount(amount); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", amount, err)
+		}
+	}
+}
+
+func TestUnitsAmount(t *testing.T) {
+	cases := []struct {
+		units  string
+		amount string
+	}{
+		{"1", "0.000000000000000001"},
+		{"999999999999999999", "0.999999999999999999"},
+		{"1000000000000000000", "1"},
+		{"1000000000000000001", "1.000000000000000001"},
+		{"1500000000000000000", "1.5"},
+		{"9999999999999999999999999999", "9999999999.999999999999999999"},
+	}
+	for _, c := range cases {
+		amount, err := unitsAmount(c.units)
+		if err != nil {
+			t.Errorf("Expected %q to convert, got: %v", c.units, err)
+			continue
+		}
+		if amount != c.amount {
+			t.Errorf("Expected %q to convert to %s, got %s", c.units, c.amount, amount)
+		}
+		// Every converted amount keeps its 10^-18 precision through transfer validation
+		if err := validateTokenAmount(amount); err != nil {
+			t.Errorf("Expected converted amount %s to be valid, got: %v", amount, err)
+		}
+	}
+
+	// Only integers are accepted
+	for _, units := range []string{"1.5", "0.000000000000000001", "1e18", "", " 1", "0x10", "one"} {
+		_, err := unitsAmount(units)
+		if err == nil || err.Error() != "units must be an integer" {
+			t.Errorf("Expected 'units must be an integer' for %q, got: %v", units, err)
+		}
+	}
+
+	// More than 28 digits converts but is rejected by transfer validation
+	amount, err := unitsAmount("99999999999999999999999999999")
+	if err != nil {
+		t.Fatalf("Expected 29 digit units to convert, got: %v", err)
+	}
+	if err := validateTokenAmount(amount); err == nil || !strings.Contains(err.Error(), "too many digits") {
+		t.Errorf("Expected 'too many digits' for amount %s, got: %v", amount, err)
+	}
+}
+
+// Driver whose transactions fail to roll back, like on a dropped connection
+type rollbackFailConnector struct{}
+
+func (c rollbackFailConnector) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c rollbackFailConnector) Driver() driver.Driver                        { return nil }
+func (c rollbackFailConnector) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c rollbackFailConnector) Close() error              { return nil }
+func (c rollbackFailConnector) Begin() (driver.Tx, error) { return c, nil }
+func (c rollbackFailConnector) Commit() error             { return nil }
+func (c rollbackFailConnector) Rollback() error           { return errors.New("connection reset") }
+
+func TestRollbackLogsFailure(t *testing.T) {
+	db := sql.OpenDB(rollbackFailConnector{})
+	defer db.Close()
+
+	var logs bytes.Buffer
+	resolver := &Resolver{DB: db, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	// Rollback after commit is the normal deferred case and stays quiet
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	resolver.rollback(tx)
+	if logs.Len() != 0 {
+		t.Errorf("Expected no log after commit, got %q", logs.String())
+	}
+
+	// A failed rollback is logged with its error
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver.rollback(tx)
+	if !strings.Contains(logs.String(), "transaction rollback failed") || !strings.Contains(logs.String(), "connection reset") {
+		t.Errorf("Expected rollback failure to be logged, got %q", logs.String())
+	}
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Delay before retry n of a transfer aborted by Postgres is n * transferRetryDelay plus jitter,
+// so that both sides of a deadlock do not collide again
+var transferRetryDelay = 10 * time.Millisecond
+
+// SQLSTATE of transactions Postgres aborts so that they can be run again, by metric label
+var retryableCodes = map[pq.ErrorCode]string{
+	"40001": "serialization_failure",
+	"40P01": "deadlock",
+}
+
+// Metric label of an error worth running the transaction again for, "" for other errors
+func retryableReason(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return retryableCodes[pqErr.Code]
+	}
+	return ""
+}
+
+// Run fn in a DB transaction and commit it
+// A serialization failure or deadlock runs fn again in a new transaction, up to TransferRetries times;
+// in optimistic mode so does a write conflict, up to OptimisticAttempts in total
+// When retries run out, the error wraps ErrWriteConflict
+func (r *Resolver) runTransferTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return r.retryTransferTx(ctx, func() error {
+		return r.transferTxAttempt(ctx, fn)
+	})
+}
+
+func (r *Resolver) retryTransferTx(ctx context.Context, attempt func() error) error {
+	conflictAttempts := 1
+	if r.LockStrategy == LockOptimistic {
+		conflictAttempts = r.OptimisticAttempts
+		if conflictAttempts <= 0 {
+			conflictAttempts = defaultOptimisticAttempts
+		}
+	}
+
+	conflicts, retries := 1, 0
+	for {
+		err := attempt()
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		if errors.Is(err, ErrWriteConflict) {
+			if conflicts >= conflictAttempts {
+				return err
+			}
+			conflicts++
+			OptimisticRetries.Inc()
+			continue
+		}
+
+		reason := retryableReason(err)
+		if reason == "" {
+			return err
+		}
+		if retries >= r.TransferRetries {
+			return fmt.Errorf("%w: %w", ErrWriteConflict, err)
+		}
+		retries++
+		TransferRetries.WithLabelValues(reason).Inc()
+
+		delay := time.Duration(retries)*transferRetryDelay + rand.N(transferRetryDelay+1)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
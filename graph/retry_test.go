@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRetryTransferTx(t *testing.T) {
+	original := transferRetryDelay
+	transferRetryDelay = time.Millisecond
+	t.Cleanup(func() { transferRetryDelay = original })
+
+	ctx := context.Background()
+	deadlock := &pq.Error{Code: "40P01"}
+
+	// Attempt failing with err the first failures times
+	failing := func(failures int, err error) (func() error, *int) {
+		calls := 0
+		return func() error {
+			calls++
+			if calls <= failures {
+				return err
+			}
+			return nil
+		}, &calls
+	}
+
+	// Deadlocks are retried until the transfer goes through
+	resolver := &Resolver{TransferRetries: 3}
+	before := testutil.ToFloat64(TransferRetries.WithLabelValues("deadlock"))
+	attempt, calls := failing(2, deadlock)
+	if err := resolver.retryTransferTx(ctx, attempt); err != nil {
+		t.Fatalf("Expected retried transfer to succeed, got: %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *calls)
+	}
+	if got := testutil.ToFloat64(TransferRetries.WithLabelValues("deadlock")) - before; got != 2 {
+		t.Errorf("Expected 2 deadlock retries counted, got %v", got)
+	}
+
+	// Serialization failures too; when retries run out the error is a write conflict
+	attempt, calls = failing(10, &pq.Error{Code: "40001"})
+	err := resolver.retryTransferTx(ctx, attempt)
+	var pqErr *pq.Error
+	if !errors.Is(err, ErrWriteConflict) || !errors.As(err, &pqErr) || pqErr.Code != "40001" {
+		t.Errorf("Expected ErrWriteConflict wrapping the serialization failure, got: %v", err)
+	}
+	if *calls != 4 {
+		t.Errorf("Expected 1 attempt and 3 retries, got %d calls", *calls)
+	}
+
+	// Without retries the first deadlock is reported
+	attempt, calls = failing(1, deadlock)
+	if err := (&Resolver{}).retryTransferTx(ctx, attempt); !errors.Is(err, ErrWriteConflict) || *calls != 1 {
+		t.Errorf("Expected single attempt failing with ErrWriteConflict, got %d calls: %v", *calls, err)
+	}
+
+	// Other errors are never retried
+	for _, failure := range []error{ErrInsufficientBalance, &pq.Error{Code: "23514"}} {
+		attempt, calls = failing(1, failure)
+		if err := resolver.retryTransferTx(ctx, attempt); err != failure || *calls != 1 {
+			t.Errorf("Expected %v after a single attempt, got %d calls: %v", failure, *calls, err)
+		}
+	}
+
+	// Write conflicts are retried in optimistic mode only, up to OptimisticAttempts
+	attempt, calls = failing(10, ErrWriteConflict)
+	if err := resolver.retryTransferTx(ctx, attempt); err != ErrWriteConflict || *calls != 1 {
+		t.Errorf("Expected single attempt outside optimistic mode, got %d calls: %v", *calls, err)
+	}
+	optimistic := &Resolver{LockStrategy: LockOptimistic, OptimisticAttempts: 2, TransferRetries: 3}
+	attempt, calls = failing(10, ErrWriteConflict)
+	if err := optimistic.retryTransferTx(ctx, attempt); err != ErrWriteConflict || *calls != 2 {
+		t.Errorf("Expected 2 attempts in optimistic mode, got %d calls: %v", *calls, err)
+	}
+
+	// Cancelled context stops retrying
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	attempt, calls = failing(10, deadlock)
+	if err := resolver.retryTransferTx(cancelled, attempt); err != deadlock || *calls != 1 {
+		t.Errorf("Expected no retry after cancellation, got %d calls: %v", *calls, err)
+	}
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+func TestAddressScalarRejectedBeforeResolver(t *testing.T) {
+	// No DB: resolvers would fail if they ran
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{}}))
+	srv.AddTransport(transport.POST{})
+
+	// Errors from parsing carry the argument in their path, resolver errors only the field
+	cases := []struct {
+		query string
+		path  string
+	}{
+		{`{ wallet(address: "0x123") { address } }`, `["wallet","address"]`},
+		{`mutation { transfer(from_address: "junk", to_address: "0xb000000000000000000000000000000000000000", amount: "1") { amount } }`, `["transfer","from_address"]`},
+		{`mutation { transferWithMemo(from_address: "0xa000000000000000000000000000000000000000", to_address: "0x` + strings.Repeat("b", 1000) + `", amount: "1") }`, `["transferWithMemo","to_address"]`},
+		{`mutation { transferScaled(from_address: "0xa000000000000000000000000000000000000000", to_address: "0xz000000000000000000000000000000000000000", units: "1", decimals: 0) }`, `["transferScaled","to_address"]`},
+	}
+	for _, c := range cases {
+		var resp map[string]any
+		err := client.New(srv).Post(c.query, &resp)
+		if err == nil || !strings.Contains(err.Error(), "invalid Ethereum address format") || !strings.Contains(err.Error(), c.path) {
+			t.Errorf("Expected invalid address error at %s, got: %v", c.path, err)
+		}
+	}
+}
+
+func TestDecimalScalarRejectedBeforeResolver(t *testing.T) {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{}}))
+	srv.AddTransport(transport.POST{})
+
+	transfer := func(amount string) string {
+		return `mutation { transfer(from_address: "0xa000000000000000000000000000000000000000", to_address: "0xb000000000000000000000000000000000000000", amount: ` + amount + `) { amount } }`
+	}
+	cases := []struct {
+		query   string
+		path    string
+		message string
+	}{
+		{transfer(`"abc"`), `["transfer","amount"]`, "invalid decimal amount"},
+		{transfer(`"-1"`), `["transfer","amount"]`, "amount must not be negative"},
+		{transfer(`1.5`), `["transfer","amount"]`, "decimal must be a string"},
+		{`mutation { mint(to_address: "0xa000000000000000000000000000000000000000", amount: "0.0000000000000000001") }`, `["mint","amount"]`, "too many decimal places"},
+		{`mutation { burn(from_address: "0xa000000000000000000000000000000000000000", amount: "1e3") }`, `["burn","amount"]`, "invalid decimal amount"},
+	}
+	for _, c := range cases {
+		var resp map[string]any
+		err := client.New(srv).Post(c.query, &resp)
+		if err == nil || !strings.Contains(err.Error(), c.message) || !strings.Contains(err.Error(), c.path) {
+			t.Errorf("Expected %q at %s, got: %v", c.message, c.path, err)
+		}
+	}
+}
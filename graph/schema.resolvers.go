@@ -7,219 +7,1420 @@ import (
 	"fmt"
 	"hash/fnv"
 	"math/big"
-	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"token_transfer/graph/model"
 
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
 // Helpers
 // Convert address to int64 using hash
-func hashAddress(address string) int64 {
+// Variable, so tests can force hash collisions
+var hashAddress = func(address string) int64 {
 	h := fnv.New64()
 	h.Write([]byte(address))
 	return int64(h.Sum64())
 }
 
-// Add advisory locks on addresses
-func (r *mutationResolver) lockWallets(tx *sql.Tx, fromAddress, toAddress string) error {
+// Advisory lock keys of a transfer, in the order they are acquired
+// locks hashes always in the same order, to avoid deadlock
+func lockKeys(fromAddress, toAddress string) []int64 {
 	senderHash := hashAddress(fromAddress)
 	recipientHash := hashAddress(toAddress)
 
-	// locks hashes always in the same order, to avoid deadlock
+	// Different addresses with the same hash share one lock; taking it twice would only stack it
+	if senderHash == recipientHash {
+		return []int64{senderHash}
+	}
+
 	if senderHash < recipientHash {
-		if err := r.lockHashAddress(tx, senderHash); err != nil {
+		return []int64{senderHash, recipientHash}
+	}
+	return []int64{recipientHash, senderHash}
+}
+
+// Lock both (address, asset) wallets of a transfer with the configured LockStrategy
+func (r *Resolver) lockWallets(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset string) error {
+	switch r.LockStrategy {
+	case LockRow:
+		return r.lockWalletRows(ctx, tx, fromAddress, toAddress, asset)
+	case LockOptimistic:
+		return nil
+	}
+
+	// Add advisory locks on wallets; transfers of different assets do not wait on each other
+	keys := lockKeys(r.lockName(fromAddress, asset), r.lockName(toAddress, asset))
+	for _, key := range keys {
+		if err := r.lockHashAddress(ctx, tx, key); err != nil {
 			return err
 		}
-		return r.lockHashAddress(tx, recipientHash)
-	} else {
-		if err := r.lockHashAddress(tx, recipientHash); err != nil {
-			return err
+	}
+
+	// Record acquisition order to diagnose deadlock/race issues
+	if r.LogLockOrder {
+		order := []string{fromAddress, toAddress}
+		if keys[0] != hashAddress(r.lockName(fromAddress, asset)) {
+			order = []string{toAddress, fromAddress}
 		}
-		return r.lockHashAddress(tx, senderHash)
+		r.logger().Info("advisory locks acquired",
+			"from", fromAddress,
+			"to", toAddress,
+			"asset", asset,
+			"lock_order", order,
+			"lock_keys", keys,
+		)
+	}
+	return nil
+}
+
+// Lock existing wallet rows with FOR UPDATE, in address order to avoid deadlock
+// Missing wallets have no row to lock; they are created later in the transaction
+func (r *Resolver) lockWalletRows(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset string) error {
+	t := r.tables()
+	query := fmt.Sprintf(`SELECT %[2]s FROM %[1]s WHERE %[2]s IN ($1, $2) AND asset = $3 ORDER BY %[2]s FOR UPDATE`, t.Wallets, t.AddressCol)
+	rows, err := tx.QueryContext(ctx, query, fromAddress, toAddress, asset)
+	if err != nil {
+		return err
 	}
+	return rows.Close()
 }
 
-func (r *mutationResolver) lockHashAddress(tx *sql.Tx, hashAddressKey int64) error {
-	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", hashAddressKey)
+func (r *Resolver) lockHashAddress(ctx context.Context, tx *sql.Tx, hashAddressKey int64) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", hashAddressKey)
 	return err
 }
 
-// Add wallet with 0 tokens
-func (r *mutationResolver) addWallet(tx *sql.Tx, address string) error {
-	query := fmt.Sprintf("INSERT INTO %s (address, token_balance) VALUES ($1, 0)", r.WalletTable)
-	_, err := tx.Exec(query, address)
+// Read wallet row selected with r.walletColumns()
+func scanWallet(row interface{ Scan(...any) error }) (*model.Wallet, error) {
+	var wallet model.Wallet
+	if err := row.Scan(&wallet.Address, &wallet.Asset, &wallet.Balance, &wallet.OwnerID, &wallet.Frozen); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// Update the wallets of every asset of an address; setClause gets the address as $1 and value as $2
+// Returns one updated wallet, the base asset one when the address has it; sql.ErrNoRows when there is none
+func (r *Resolver) updateAddressWallets(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, setClause, address string, value any) (*model.Wallet, error) {
+	t := r.tables()
+	query := fmt.Sprintf(`WITH updated AS (UPDATE %s SET %s WHERE %s = $1 RETURNING %s)
+		SELECT %s FROM updated ORDER BY asset <> $3, asset LIMIT 1`, t.Wallets, setClause, t.AddressCol, r.walletColumns(), r.walletColumns())
+	return scanWallet(q.QueryRowContext(ctx, query, address, value, r.baseAsset()))
+}
+
+// Add wallet of asset with 0 tokens
+// Owner and frozen flag are taken over from wallets the address has for other assets
+// Does nothing if a concurrent transfer has already created it
+func (r *Resolver) addWallet(ctx context.Context, tx *sql.Tx, address, asset string) error {
+	_, err := txExec(ctx, tx, r.statements.addWallet, r.addWalletQuery(), address, asset)
 
 	return err
 }
 
-// Return token_balance as string
-func (r *mutationResolver) getTokenBalance(tx *sql.Tx, address string) (string, error) {
+func (r *Resolver) addWalletQuery() string {
+	t := r.tables()
+	return fmt.Sprintf(`INSERT INTO %[1]s (%[2]s, asset, %[3]s, owner_id, frozen)
+		SELECT $1, $2, 0, MAX(owner_id), COALESCE(BOOL_OR(frozen), FALSE) FROM %[1]s WHERE %[2]s = $1
+		ON CONFLICT (%[2]s, asset) DO NOTHING`, t.Wallets, t.AddressCol, t.BalanceCol)
+}
+
+// Add sender wallet with default starting balance and return its balance
+// A concurrent transfer may have created it first: its row is kept and that balance returned
+func (r *Resolver) addSenderWallet(ctx context.Context, tx *sql.Tx, address, asset string) (string, error) {
+	t := r.tables()
+	query := fmt.Sprintf("INSERT INTO %[1]s (%[2]s, asset, %[3]s) VALUES ($1, $2, $3::numeric) ON CONFLICT (%[2]s, asset) DO NOTHING", t.Wallets, t.AddressCol, t.BalanceCol)
+	if _, err := tx.ExecContext(ctx, query, address, asset, r.DefaultSenderBalance.String()); err != nil {
+		return "", err
+	}
+
+	return r.getTokenBalance(ctx, tx, address, asset)
+}
+
+// Return token_balance as string, checked against NUMERIC(28,18)
+func (r *Resolver) getTokenBalance(ctx context.Context, tx *sql.Tx, address, asset string) (string, error) {
 	var balance string
-	query := fmt.Sprintf("SELECT token_balance FROM %s WHERE address = $1", r.WalletTable)
-	err := tx.QueryRow(query, address).Scan(&balance)
+	if err := txQueryRow(ctx, tx, r.statements.tokenBalance, r.tokenBalanceQuery(), address, asset).Scan(&balance); err != nil {
+		return "", err
+	}
 
-	return balance, err
+	return r.checkStoredBalance(address, balance)
+}
+
+func (r *Resolver) tokenBalanceQuery() string {
+	t := r.tables()
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND asset = $2", t.BalanceCol, t.Wallets, t.AddressCol)
+}
+
+// Read sender balance and recipient existence in one round trip, locking both rows
+// In optimistic mode rows are not locked; their versions are checked by the updates instead
+// Missing sender returns a "sender wallet does not exist" error matching sql.ErrNoRows; sender balance is checked against NUMERIC(28,18)
+// A frozen sender or recipient returns ErrWalletFrozen
+func (r *Resolver) getTransferWallets(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset string) (transferWallets, error) {
+	rows, err := txQuery(ctx, tx, r.statements.transferWallets, r.transferWalletsQuery(), fromAddress, toAddress, asset)
+	if err != nil {
+		return transferWallets{}, err
+	}
+	defer rows.Close()
+
+	var wallets transferWallets
+	senderExists := false
+	for rows.Next() {
+		var address, balance string
+		var frozen bool
+		var version int64
+		if err := rows.Scan(&address, &balance, &frozen, &version); err != nil {
+			return transferWallets{}, err
+		}
+		// Checked under the row lock (or the version check), so a concurrent unfreeze cannot slip in before commit
+		if frozen {
+			return transferWallets{}, fmt.Errorf("%w: %s", ErrWalletFrozen, address)
+		}
+		switch address {
+		case fromAddress:
+			senderExists = true
+			wallets.senderBalance, wallets.senderVersion = balance, version
+		case toAddress:
+			wallets.recipientExists, wallets.recipientVersion = true, version
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return transferWallets{}, err
+	}
+
+	if !senderExists {
+		return wallets, &notFoundError{"sender wallet does not exist: " + fromAddress}
+	}
+	wallets.senderBalance, err = r.checkStoredBalance(fromAddress, wallets.senderBalance)
+	return wallets, err
+}
+
+func (r *Resolver) transferWalletsQuery() string {
+	lockClause := " FOR UPDATE"
+	if r.LockStrategy == LockOptimistic {
+		lockClause = ""
+	}
+	t := r.tables()
+	return fmt.Sprintf(`SELECT %[2]s, %[3]s, frozen, version FROM %[1]s WHERE %[2]s IN ($1, $2) AND asset = $3 ORDER BY %[2]s%[4]s`,
+		t.Wallets, t.AddressCol, t.BalanceCol, lockClause)
 }
 
 // Update balances; explicit cast amount from string to numeric
-func (r *mutationResolver) updateBalances(tx *sql.Tx, fromAddress, toAddress string, amount string) error {
+// Debit is guarded in SQL, so balance can never go below zero
+// Every balance change increments the wallet version
+// Rows are updated in address order, so optimistic transfers, which lock them only here, cannot deadlock
+// Returns new recipient balance
+func (r *Resolver) updateBalances(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset, amount string, wallets transferWallets) (string, error) {
+	if toAddress < fromAddress {
+		recipientBalance, err := r.creditWallet(ctx, tx, toAddress, asset, amount, wallets.recipientVersion)
+		if err != nil {
+			return "", err
+		}
+		return recipientBalance, r.debitWallet(ctx, tx, fromAddress, asset, amount, wallets.senderVersion)
+	}
+
+	if err := r.debitWallet(ctx, tx, fromAddress, asset, amount, wallets.senderVersion); err != nil {
+		return "", err
+	}
+	return r.creditWallet(ctx, tx, toAddress, asset, amount, wallets.recipientVersion)
+}
+
+// Add amount and return new balance
+func (r *Resolver) creditWallet(ctx context.Context, tx *sql.Tx, address, asset, amount string, version int64) (string, error) {
+	var balance string
+	err := txQueryRow(ctx, tx, r.statements.credit, r.creditQuery(), r.versionArgs([]any{amount, address, asset}, version)...).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) && r.LockStrategy == LockOptimistic {
+		return "", ErrWriteConflict
+	}
+
+	return balance, err
+}
 
-	query := fmt.Sprintf(`UPDATE %s SET token_balance = token_balance - $1::numeric WHERE address = $2`, r.WalletTable)
-	_, err := tx.Exec(query, amount, fromAddress)
+func (r *Resolver) creditQuery() string {
+	t := r.tables()
+	return fmt.Sprintf(`UPDATE %[1]s SET %[3]s = %[3]s + $1::numeric, version = version + 1 WHERE %[2]s = $2 AND asset = $3%[4]s
+		RETURNING %[3]s`, t.Wallets, t.AddressCol, t.BalanceCol, r.versionGuard("$4"))
+}
 
+// Subtract amount only if balance covers it, in a single statement
+// When no row was updated, check existence to return a not-found error or ErrInsufficientBalance
+// In optimistic mode the balance was read at the same version, so no updated row means a write conflict
+func (r *Resolver) debitWallet(ctx context.Context, tx *sql.Tx, address, asset, amount string, version int64) error {
+	result, err := txExec(ctx, tx, r.statements.debit, r.debitQuery(), r.versionArgs([]any{amount, address, asset}, version)...)
 	if err != nil {
 		return err
 	}
-	query = fmt.Sprintf(`UPDATE %s SET token_balance = token_balance + $1::numeric WHERE address = $2`, r.WalletTable)
-	_, err = tx.Exec(query, amount, toAddress)
 
-	return err
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		return nil
+	}
+	if r.LockStrategy == LockOptimistic {
+		return ErrWriteConflict
+	}
+
+	var exists bool
+	t := r.tables()
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1 AND asset = $2)`, t.Wallets, t.AddressCol)
+	if err := tx.QueryRowContext(ctx, query, address, asset).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return &notFoundError{"sender wallet does not exist: " + address}
+	}
+	return ErrInsufficientBalance
+}
+
+func (r *Resolver) debitQuery() string {
+	t := r.tables()
+	return fmt.Sprintf(`UPDATE %[1]s SET %[3]s = %[3]s - $1::numeric, version = version + 1
+		WHERE %[2]s = $2 AND asset = $3 AND %[3]s >= $1::numeric%[4]s`, t.Wallets, t.AddressCol, t.BalanceCol, r.versionGuard("$4"))
+}
+
+// Condition on the wallet version for an UPDATE in optimistic mode
+// Other lock strategies hold row or advisory locks, so no condition is needed
+func (r *Resolver) versionGuard(placeholder string) string {
+	if r.LockStrategy != LockOptimistic {
+		return ""
+	}
+	return " AND version = " + placeholder
+}
+
+// Arguments of an UPDATE with versionGuard
+func (r *Resolver) versionArgs(args []any, version int64) []any {
+	if r.LockStrategy != LockOptimistic {
+		return args
+	}
+	return append(args, version)
+}
+
+// Error returned when input does not pass validation
+// reason is a short label used in metrics and logs
+type validationError struct {
+	reason  string
+	message string
+}
+
+func (e *validationError) Error() string {
+	return e.message
+}
+
+// Sentinel error matching reason, if any
+func (e *validationError) Unwrap() error {
+	return validationSentinels[e.reason]
+}
+
+// Count rejected input and log it if enabled, attributed to the source of the request
+func (r *Resolver) recordValidationFailure(ctx context.Context, err error) {
+	var validationErr *validationError
+	if !errors.As(err, &validationErr) {
+		return
+	}
+
+	ValidationFailures.WithLabelValues(validationErr.reason, metricSourceFromContext(ctx)).Inc()
+
+	if r.LogValidationFailures {
+		r.logger().Warn("validation failed",
+			"reason", validationErr.reason,
+			"source", sourceFromContext(ctx),
+			"error", err.Error(),
+		)
+	}
 }
 
 // Validate if token count checks the contraints of DB => NUMERIC(28, 18)
 func validateTokenAmount(amount string) error {
-	amountDecimal, err := decimal.NewFromString(amount)
-	if err != nil {
-		return fmt.Errorf("invalid decimal amount")
+	// Same rules as the Decimal scalar, for callers not going through GraphQL
+	amountDecimal, err := model.ParseDecimal(amount)
+	var decimalErr *model.DecimalError
+	if errors.As(err, &decimalErr) {
+		return &validationError{decimalErr.Reason, decimalErr.Message}
 	}
 
 	if amountDecimal.Cmp(decimal.Zero) <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+		return &validationError{"non_positive_amount", "amount must be greater than zero"}
+	}
+	return nil
+}
+
+// Check transfer amount against configured policy; amount must already be valid
+func (r *Resolver) checkAmountLimits(amount string) error {
+	amountDecimal := decimal.RequireFromString(amount)
+	if amountDecimal.LessThan(r.MinTransferAmount) {
+		return &validationError{"amount_too_small", "amount below minimum"}
+	}
+	if r.MaxTransferAmount.IsPositive() && amountDecimal.GreaterThan(r.MaxTransferAmount) {
+		return &validationError{"amount_too_large", "amount exceeds maximum allowed transfer"}
+	}
+	return nil
+}
+
+// Compare actual balance with balance expected by client (compare-and-swap guard)
+func checkExpectedBalance(actual, expected string) error {
+	expectedDecimal, err := decimal.NewFromString(expected)
+	if err != nil {
+		return &validationError{"invalid_expected_balance", "invalid expected sender balance"}
 	}
 
-	if amountDecimal.Exponent() < -18 {
-		return fmt.Errorf("too many decimal places: max 18 allowed")
+	actualDecimal, err := decimal.NewFromString(actual)
+	if err != nil {
+		return fmt.Errorf("invalid sender balance format in DB")
 	}
 
-	// Check if amount does not have more than 28 digits
-	coeff := amountDecimal.Coefficient()
-	totalDigits := len(coeff.String())
-	if totalDigits > 28 {
-		return fmt.Errorf("too many digits: max precision is 28")
+	if !actualDecimal.Equal(expectedDecimal) {
+		return fmt.Errorf("balance changed, please retry")
 	}
 	return nil
 }
 
 func validateDifferentAddresses(from, to string) error {
 	if strings.EqualFold(from, to) {
-		return fmt.Errorf("sender and recipient addresses must be different")
+		return &validationError{"same_address", "sender and recipient addresses must be different"}
 	}
 	return nil
 }
 
+// Same check as the Address scalar, for callers not going through GraphQL
 func validateEthereumAddress(address string) error {
-	var ethAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	if !model.ValidAddress(address) {
+		return &validationError{"invalid_address", "invalid Ethereum address format"}
+	}
+	return nil
+}
+
+// Canonical lowercase form of address, used as the key of every wallet and transaction
+// so that checksum-cased and lowercase variants refer to the same wallet
+func normalizeAddress(address string) string {
+	return strings.ToLower(address)
+}
+
+// Windows accepted by transferRate
+var rateWindows = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// Number of flowMatrix edges returned by default and at most
+const (
+	defaultFlowEdges = 10
+	maxFlowEdges     = 100
+)
+
+// Format decimal with 18 decimal places and explicit sign: "+1.5...", "-1.5...", "0.0..."
+func formatSigned(value decimal.Decimal) string {
+	if value.IsPositive() {
+		return "+" + value.StringFixed(18)
+	}
+	return value.StringFixed(18)
+}
+
+// Build decimal amount from integer units and decimal scale: units * 10^-decimals
+func scaledAmount(units string, decimals int32) (string, error) {
+	if decimals < 0 || decimals > 18 {
+		return "", &validationError{"invalid_decimals", "decimals must be between 0 and 18"}
+	}
+
+	unitsInt, ok := new(big.Int).SetString(units, 10)
+	if !ok {
+		return "", &validationError{"invalid_units", "units must be an integer"}
+	}
+
+	return decimal.NewFromBigInt(unitsInt, -decimals).String(), nil
+}
+
+// Build decimal amount from integer base units, the smallest amount NUMERIC(28,18) can hold
+func unitsAmount(units string) (string, error) {
+	return scaledAmount(units, balanceScale)
+}
 
-	if !ethAddressRegex.MatchString(address) {
-		return fmt.Errorf("invalid Ethereum address format")
+// Max length of external owner ID
+const maxOwnerIDLength = 128
+
+func validateOwnerID(ownerID string) error {
+	if strings.TrimSpace(ownerID) == "" || len(ownerID) > maxOwnerIDLength {
+		return &validationError{"invalid_owner_id", fmt.Sprintf("owner ID must be 1-%d characters", maxOwnerIDLength)}
 	}
 	return nil
 }
 
-// Resolver for the transfer field
-func (r *mutationResolver) Transfer(ctx context.Context, fromAddress string, toAddress string, amount string) (string, error) {
-	tx, err := r.DB.Begin()
-	if err != nil {
-		return "", err
+// Max length of transfer memo, in characters
+const maxMemoLength = 256
+
+func validateMemo(memo string) error {
+	if utf8.RuneCountInString(memo) > maxMemoLength {
+		return &validationError{"invalid_memo", fmt.Sprintf("memo must be at most %d characters", maxMemoLength)}
+	}
+	if strings.IndexFunc(memo, unicode.IsControl) >= 0 {
+		return &validationError{"invalid_memo", "memo must not contain control characters"}
 	}
-	defer tx.Rollback()
+	return nil
+}
 
+// Validate transfer input before touching the DB
+func validateTransferInput(fromAddress, toAddress, amount string) error {
 	// Validate addressess
 	if err := validateDifferentAddresses(fromAddress, toAddress); err != nil {
-		return "", err
+		return err
 	}
 
 	if err := validateEthereumAddress(fromAddress); err != nil {
-		return "", fmt.Errorf("fromAddress invalid: %w", err)
+		return fmt.Errorf("fromAddress invalid: %w", err)
 	}
 
 	if err := validateEthereumAddress(toAddress); err != nil {
-		return "", fmt.Errorf("toAddress invalid: %w", err)
+		return fmt.Errorf("toAddress invalid: %w", err)
 	}
 
 	// Validate amount
-	if err := validateTokenAmount(amount); err != nil {
+	return validateTokenAmount(amount)
+}
+
+// Resolver for the transfer field
+func (r *mutationResolver) Transfer(ctx context.Context, fromAddress string, toAddress string, amount string, expectedSenderBalance *string, asset *string) (*model.TransferResult, error) {
+	result, receipt, err := r.Service().TransferWithOptions(ctx, fromAddress, toAddress, amount, TransferOptions{ExpectedSenderBalance: expectedSenderBalance, Asset: asset})
+	if err != nil {
+		return nil, err
+	}
+	registerReceipt(ctx, receipt)
+	return result, nil
+}
+
+// Resolver for the transferWithMemo field
+func (r *mutationResolver) TransferWithMemo(ctx context.Context, fromAddress string, toAddress string, amount string, memo *string) (string, error) {
+	result, receipt, err := r.Service().TransferWithOptions(ctx, fromAddress, toAddress, amount, TransferOptions{Memo: memo})
+	if err != nil {
 		return "", err
 	}
+	registerReceipt(ctx, receipt)
+	return result.SenderBalance, nil
+}
+
+// Resolver for the approve field
+func (r *mutationResolver) Approve(ctx context.Context, ownerAddress string, spenderAddress string, amount string) (*model.Allowance, error) {
+	if r.AllowanceTable == "" {
+		return nil, fmt.Errorf("allowances are disabled")
+	}
+
+	// Validate addresses and amount
+	if err := validateDifferentAddresses(ownerAddress, spenderAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+	if err := validateEthereumAddress(ownerAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, fmt.Errorf("ownerAddress invalid: %w", err)
+	}
+	if err := validateEthereumAddress(spenderAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, fmt.Errorf("spenderAddress invalid: %w", err)
+	}
+	if err := validateAllowanceAmount(amount); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+	ownerAddress, spenderAddress = normalizeAddress(ownerAddress), normalizeAddress(spenderAddress)
+
+	stored, err := r.setAllowance(ctx, ownerAddress, spenderAddress, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Allowance{OwnerAddress: ownerAddress, SpenderAddress: spenderAddress, Amount: stored}, nil
+}
+
+// Resolver for the transferFrom field
+func (r *mutationResolver) TransferFrom(ctx context.Context, spenderAddress string, fromAddress string, toAddress string, amount string) (*model.TransferResult, error) {
+	result, receipt, err := r.Service().TransferWithOptions(ctx, fromAddress, toAddress, amount, TransferOptions{Spender: &spenderAddress})
+	if err != nil {
+		return nil, err
+	}
+	registerReceipt(ctx, receipt)
+	return result, nil
+}
+
+// Transfer response with amount in the same NUMERIC(28,18) form as balances
+// Called right after commit, so without history the commit time is used as timestamp
+func newTransferResult(fromAddress, toAddress, amount string, result transferResult) *model.TransferResult {
+	timestamp := result.timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	return &model.TransferResult{
+		FromAddress:      fromAddress,
+		ToAddress:        toAddress,
+		Asset:            result.asset,
+		SenderBalance:    result.senderBalance,
+		RecipientBalance: result.recipientBalance,
+		Amount:           decimal.RequireFromString(amount).StringFixed(18),
+		Timestamp:        timestamp,
+	}
+}
+
+// Report expired deadline or Postgres lock_timeout as ErrTransferTimeout
+func transferTimeoutError(ctx context.Context, err error) error {
+	var pqErr *pq.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (errors.As(err, &pqErr) && pqErr.Code == "55P03") {
+		return fmt.Errorf("%w: %v", ErrTransferTimeout, err)
+	}
+	return err
+}
+
+// Make lock waits of tx fail after TransferTimeout in Postgres too, even if the client deadline is not enforced
+func (r *Resolver) setLockTimeout(ctx context.Context, tx *sql.Tx) error {
+	if r.TransferTimeout <= 0 {
+		return nil
+	}
+	lockTimeout := fmt.Sprintf("%dms", r.TransferTimeout.Milliseconds())
+	_, err := tx.ExecContext(ctx, "SELECT set_config('lock_timeout', $1, true)", lockTimeout)
+	return err
+}
+
+// Balances and receipt of a transfer moved inside a DB transaction
+type transferResult struct {
+	asset            string
+	senderBalance    string
+	recipientBalance string
+	receipt          string    // empty when history is disabled
+	timestamp        time.Time // created_at in the transaction log; zero when history is disabled
+}
+
+// Move tokens inside given transaction, without committing it
+func (r *Resolver) transferInTx(ctx context.Context, tx *sql.Tx, fromAddress, toAddress, asset, amount string, expectedSenderBalance *string, memo string) (transferResult, error) {
+	if err := r.setLockTimeout(ctx, tx); err != nil {
+		return transferResult{}, err
+	}
 
 	// Add advisory lock for server and recipient
 	// If other transactions try to add lock, they will have to wait
 	// until the end of transaction
-	if err := r.lockWallets(tx, fromAddress, toAddress); err != nil {
-		return "", err
+	_, span := tracer.Start(ctx, "lockWallets")
+	err := r.lockWallets(ctx, tx, fromAddress, toAddress, asset)
+	endSpan(span, err)
+	if err != nil {
+		return transferResult{}, err
 	}
 
-	// Get sender balance in string
-	senderBalanceStr, err := r.getTokenBalance(tx, fromAddress)
+	// Get sender balance in string and check if recipient wallet exists
+	_, span = tracer.Start(ctx, "getTransferWallets")
+	wallets, err := r.getTransferWallets(ctx, tx, fromAddress, toAddress, asset)
+	if errors.Is(err, sql.ErrNoRows) && r.AutoCreateSender {
+		// Sender does not exist - create it with default balance
+		wallets.senderBalance, err = r.addSenderWallet(ctx, tx, fromAddress, asset)
+	}
+	endSpan(span, err)
 	if err != nil {
-		return "", err
+		return transferResult{}, err
 	}
+	senderBalanceStr := wallets.senderBalance
 
 	// Parse sender balance and amount into big.Rat
 	senderBalance := new(big.Rat)
 	if _, ok := senderBalance.SetString(senderBalanceStr); !ok {
-		return "", fmt.Errorf("invalid sender balance format in DB")
+		return transferResult{}, fmt.Errorf("invalid sender balance format in DB")
 	}
 	transferAmount := new(big.Rat)
 	if _, ok := transferAmount.SetString(amount); !ok {
-		return "", fmt.Errorf("invalid transfer amount format")
+		return transferResult{}, fmt.Errorf("invalid transfer amount format")
+	}
+
+	// Check if sender balance did not change since client read it
+	if expectedSenderBalance != nil {
+		if err := checkExpectedBalance(senderBalanceStr, *expectedSenderBalance); err != nil {
+			r.recordValidationFailure(ctx, err)
+			return transferResult{}, err
+		}
 	}
 
 	// Check balance of the sender
 	if senderBalance.Cmp(transferAmount) < 0 {
-		return "", fmt.Errorf("insufficient balance")
+		// Treasury running dry is a critical operational alert
+		if r.isTreasury(fromAddress) && asset == r.baseAsset() {
+			TreasuryInsufficientBalance.Inc()
+			r.logger().Error("treasury insufficient balance",
+				"treasury", fromAddress,
+				"balance", senderBalanceStr,
+				"amount", amount,
+			)
+			return transferResult{}, fmt.Errorf("treasury %w", ErrInsufficientBalance)
+		}
+		return transferResult{}, ErrInsufficientBalance
 	}
 
-	// Check if recipient wallet exists
-	// If not - add it to DB
-	_, err = r.getTokenBalance(tx, toAddress)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			if err := r.addWallet(tx, toAddress); err != nil {
-				return "", err
-			}
-		} else {
-			return "", err
+	// Add recipient wallet to DB if it does not exist
+	if !wallets.recipientExists {
+		// Reject dust that would only create a wallet with unusable balance
+		if decimal.RequireFromString(amount).LessThan(r.NewWalletMinAmount) {
+			return transferResult{}, fmt.Errorf("amount below minimum for new wallet")
+		}
+
+		if err := r.addWallet(ctx, tx, toAddress, asset); err != nil {
+			return transferResult{}, err
 		}
 	}
 
 	// Update token balances
-	if err := r.updateBalances(tx, fromAddress, toAddress, amount); err != nil {
-		return "", err
+	_, span = tracer.Start(ctx, "updateBalances")
+	recipientBalance, err := r.updateBalances(ctx, tx, fromAddress, toAddress, asset, amount, wallets)
+	endSpan(span, err)
+	if err != nil {
+		return transferResult{}, err
 	}
 
-	// Commit
-	if err := tx.Commit(); err != nil {
-		return "", err
+	// Append transfer to the hash-chained transaction log
+	var receipt string
+	var recordedAt time.Time
+	if r.TransactionTable != "" {
+		receipt, recordedAt, err = r.recordTransaction(ctx, tx, fromAddress, toAddress, asset, amount, memo)
+		if err != nil {
+			return transferResult{}, err
+		}
 	}
 
 	// Return new sender balance as a string
 	newSenderBalance := new(big.Rat).Sub(senderBalance, transferAmount)
-	return newSenderBalance.FloatString(18), nil
+	return transferResult{
+		asset:            asset,
+		senderBalance:    newSenderBalance.FloatString(18),
+		recipientBalance: recipientBalance,
+		receipt:          receipt,
+		timestamp:        recordedAt,
+	}, nil
 }
 
-// Resolver for the wallet field
-func (r *queryResolver) Wallet(ctx context.Context, address string) (*model.Wallet, error) {
-	query := fmt.Sprintf("SELECT address, token_balance FROM %s WHERE address = $1", r.WalletTable)
-	row := r.DB.QueryRow(query, address)
+// Resolver for the importLedger field
+func (r *mutationResolver) ImportLedger(ctx context.Context, ledger string) (*model.LedgerSummary, error) {
+	if !r.LedgerImportEnabled {
+		return nil, fmt.Errorf("ledger import is disabled")
+	}
+	return r.importLedger(ctx, ledger)
+}
 
-	var wallet model.Wallet
-	err := row.Scan(&wallet.Address, &wallet.Balance)
+// Resolver for the linkWallet field
+func (r *mutationResolver) LinkWallet(ctx context.Context, address string, ownerID string) (*model.Wallet, error) {
+	if err := validateEthereumAddress(address); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+	address = normalizeAddress(address)
+
+	if err := validateOwnerID(ownerID); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer r.rollback(tx)
 
-	return &wallet, nil
+	// Lock wallet rows of every asset, so concurrent links see each other
+	t := r.tables()
+	query := fmt.Sprintf("SELECT owner_id FROM %s WHERE %s = $1 FOR UPDATE", t.Wallets, t.AddressCol)
+	rows, err := tx.QueryContext(ctx, query, address)
+	if err != nil {
+		return nil, err
+	}
+	exists, linkedElsewhere := false, false
+	for rows.Next() {
+		var currentOwner sql.NullString
+		if err := rows.Scan(&currentOwner); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		exists = true
+		linkedElsewhere = linkedElsewhere || (currentOwner.Valid && currentOwner.String != ownerID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	// Wallet can belong to one owner only, unless re-linking is allowed
+	if linkedElsewhere && !r.AllowOwnerRelink {
+		return nil, fmt.Errorf("wallet already linked to another owner")
+	}
+
+	wallet, err := r.updateAddressWallets(ctx, tx, "owner_id = $2", address, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
 }
 
-// Mutation returns MutationResolver implementation
-func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+// Resolver for the freezeWallet field
+func (r *mutationResolver) FreezeWallet(ctx context.Context, address string) (*model.Wallet, error) {
+	return r.setWalletFrozen(ctx, address, true)
+}
 
-// Query returns QueryResolver implementation
-func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+// Resolver for the unfreezeWallet field
+func (r *mutationResolver) UnfreezeWallet(ctx context.Context, address string) (*model.Wallet, error) {
+	return r.setWalletFrozen(ctx, address, false)
+}
+
+// Set frozen flag of an existing wallet, for every asset it holds
+// The UPDATE waits for the row lock of any transfer in progress, so that transfer completes with the old flag
+func (r *mutationResolver) setWalletFrozen(ctx context.Context, address string, frozen bool) (*model.Wallet, error) {
+	if err := validateEthereumAddress(address); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+	address = normalizeAddress(address)
+
+	wallet, err := r.updateAddressWallets(ctx, r.DB, "frozen = $2, version = version + 1", address, frozen)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger().Info("wallet frozen flag changed", "address", address, "frozen", frozen, "actor", actorFromContext(ctx))
+	return wallet, nil
+}
+
+// Resolver for the mint field
+func (r *mutationResolver) Mint(ctx context.Context, toAddress string, amount string, asset *string) (string, error) {
+	if !r.MintEnabled {
+		return "", fmt.Errorf("minting is disabled")
+	}
+
+	// Validate address and amount
+	if err := validateEthereumAddress(toAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", fmt.Errorf("toAddress invalid: %w", err)
+	}
+	toAddress = normalizeAddress(toAddress)
+	if r.TreasurySendOnly && r.isTreasury(toAddress) {
+		return "", fmt.Errorf("minting to treasury is disabled")
+	}
+
+	if err := validateTokenAmount(amount); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", err
+	}
+	mintAsset, err := r.requestAsset(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+
+	amountDecimal, _ := decimal.NewFromString(amount)
+
+	var newBalance string
+	err = r.runTransferTx(ctx, func(tx *sql.Tx) error {
+		// Lock recipient wallet like a transfer to it
+		if err := r.lockWallets(ctx, tx, toAddress, toAddress, mintAsset); err != nil {
+			return err
+		}
+
+		// Create recipient wallet if it does not exist
+		balanceStr, err := r.getTokenBalance(ctx, tx, toAddress, mintAsset)
+		if errors.Is(err, sql.ErrNoRows) {
+			balanceStr = "0"
+			err = r.addWallet(ctx, tx, toAddress, mintAsset)
+		}
+		if err != nil {
+			return err
+		}
+		version, err := r.walletVersion(ctx, tx, toAddress, mintAsset)
+		if err != nil {
+			return err
+		}
+
+		balance, err := decimal.NewFromString(balanceStr)
+		if err != nil {
+			return fmt.Errorf("invalid balance format in DB")
+		}
+		if err := checkBalanceFits(balance.Add(amountDecimal)); err != nil {
+			return err
+		}
+
+		newBalance, err = r.creditWallet(ctx, tx, toAddress, mintAsset, amount, version)
+		if err != nil {
+			return err
+		}
+
+		// New tokens come from supplyAddress in the log, so the wallet still reconciles
+		if r.TransactionTable != "" {
+			_, _, err = r.recordTransaction(ctx, tx, supplyAddress, toAddress, mintAsset, amount, "")
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newBalance, nil
+}
+
+// Resolver for the burn field
+func (r *mutationResolver) Burn(ctx context.Context, fromAddress string, amount string, asset *string) (string, error) {
+	// Validate address and amount
+	if err := validateEthereumAddress(fromAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", fmt.Errorf("fromAddress invalid: %w", err)
+	}
+	fromAddress = normalizeAddress(fromAddress)
+
+	if err := validateTokenAmount(amount); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", err
+	}
+
+	burnAsset, err := r.requestAsset(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+
+	// Protected treasury can be spent only with a reason
+	if r.TreasuryProtected && r.isTreasury(fromAddress) {
+		return "", fmt.Errorf("burning from treasury is not allowed")
+	}
+
+	burnAmount := new(big.Rat)
+	if _, ok := burnAmount.SetString(amount); !ok {
+		return "", fmt.Errorf("invalid burn amount format")
+	}
+
+	var remaining string
+	err = r.runTransferTx(ctx, func(tx *sql.Tx) error {
+		// Lock wallet like a transfer from it
+		if err := r.lockWallets(ctx, tx, fromAddress, fromAddress, burnAsset); err != nil {
+			return err
+		}
+
+		balanceStr, err := r.getTokenBalance(ctx, tx, fromAddress, burnAsset)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("wallet does not exist")
+		}
+		if err != nil {
+			return err
+		}
+		version, err := r.walletVersion(ctx, tx, fromAddress, burnAsset)
+		if err != nil {
+			return err
+		}
+
+		// Balance can never go negative; in optimistic mode the guarded update below
+		// only reports a write conflict, so the balance is checked here first
+		balance := new(big.Rat)
+		if _, ok := balance.SetString(balanceStr); !ok {
+			return fmt.Errorf("invalid balance format in DB")
+		}
+		if balance.Cmp(burnAmount) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := r.debitWallet(ctx, tx, fromAddress, burnAsset, amount, version); err != nil {
+			return err
+		}
+		remaining, err = r.getTokenBalance(ctx, tx, fromAddress, burnAsset)
+		if err != nil {
+			return err
+		}
+
+		// Burned tokens go to supplyAddress in the log, so the wallet still reconciles
+		if r.TransactionTable != "" {
+			_, _, err = r.recordTransaction(ctx, tx, fromAddress, supplyAddress, burnAsset, amount, "")
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return remaining, nil
+}
+
+// Resolver for the treasuryTransfer field
+func (r *mutationResolver) TreasuryTransfer(ctx context.Context, toAddress string, amount string, reason string) (string, error) {
+	result, receipt, err := r.Service().TransferWithOptions(ctx, r.TreasuryAddress, toAddress, amount, TransferOptions{TreasuryReason: &reason})
+	if err != nil {
+		return "", err
+	}
+	registerReceipt(ctx, receipt)
+	return result.SenderBalance, nil
+}
+
+// Resolver for the transferScaled field
+func (r *mutationResolver) TransferScaled(ctx context.Context, fromAddress string, toAddress string, units string, decimals int32) (string, error) {
+	// Reconstruct decimal amount; NUMERIC(28,18) constraints are checked by Transfer
+	amount, err := scaledAmount(units, decimals)
+	if err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", err
+	}
+
+	result, err := r.Transfer(ctx, fromAddress, toAddress, amount, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.SenderBalance, nil
+}
+
+// Resolver for the transferUnits field
+func (r *mutationResolver) TransferUnits(ctx context.Context, fromAddress string, toAddress string, units string) (*model.TransferResult, error) {
+	// Base units have no fractional part; NUMERIC(28,18) range is checked by Transfer
+	amount, err := unitsAmount(units)
+	if err != nil {
+		r.recordValidationFailure(ctx, err)
+		return nil, err
+	}
+
+	return r.Transfer(ctx, fromAddress, toAddress, amount, nil, nil)
+}
+
+// Resolver for the wallet field
+func (r *queryResolver) Wallet(ctx context.Context, address string, asset *string) (*model.Wallet, error) {
+	walletAsset, err := r.requestAsset(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND asset = $2", r.walletColumns(), t.Wallets, t.AddressCol)
+	row := r.readDB().QueryRowContext(ctx, query, normalizeAddress(address), walletAsset)
+
+	wallet, err := scanWallet(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &notFoundError{"wallet not found: " + normalizeAddress(address)}
+	}
+	return wallet, err
+}
+
+// Resolver for the walletExists field
+func (r *queryResolver) WalletExists(ctx context.Context, address string) (bool, error) {
+	if err := validateEthereumAddress(address); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	t := r.tables()
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1)", t.Wallets, t.AddressCol)
+	if err := r.readDB().QueryRowContext(ctx, query, normalizeAddress(address)).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Resolver for the balance field
+func (r *queryResolver) Balance(ctx context.Context, address string) (*model.Balance, error) {
+	if err := validateEthereumAddress(address); err != nil {
+		return nil, err
+	}
+	address = normalizeAddress(address)
+
+	// Same query as transfers read balances with; statements are prepared on the primary only
+	var row *sql.Row
+	if stmt := r.statements.tokenBalance; stmt != nil && r.ReadDB == nil {
+		row = stmt.QueryRowContext(ctx, address, r.baseAsset())
+	} else {
+		row = r.readDB().QueryRowContext(ctx, r.tokenBalanceQuery(), address, r.baseAsset())
+	}
+
+	var stored string
+	err := row.Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &notFoundError{"wallet not found: " + address}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked against NUMERIC(28,18), so the balance converts to whole units
+	stored, err = r.checkStoredBalance(address, stored)
+	if err != nil {
+		return nil, err
+	}
+	value := decimal.RequireFromString(stored)
+	return &model.Balance{
+		Address: address,
+		Balance: value.String(),
+		Units:   balanceUnits(value),
+	}, nil
+}
+
+// Resolver for the walletsByOwner field
+func (r *queryResolver) WalletsByOwner(ctx context.Context, ownerID string) ([]*model.Wallet, error) {
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE owner_id = $1 ORDER BY %s, asset", r.walletColumns(), t.Wallets, t.AddressCol)
+	rows, err := r.DB.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []*model.Wallet{}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// Resolver for the balances field
+func (r *queryResolver) Balances(ctx context.Context, addresses []string) ([]*model.WalletBalance, error) {
+	return r.walletBalances(ctx, addresses)
+}
+
+// Resolver for the wallets field
+func (r *queryResolver) Wallets(ctx context.Context, first *int32, after *string) (*model.WalletConnection, error) {
+	return r.listWallets(ctx, first, after)
+}
+
+// Resolver for the totalSupply field
+func (r *queryResolver) TotalSupply(ctx context.Context, asset *string) (string, error) {
+	supplyAsset, err := r.requestAsset(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+
+	var supply decimal.Decimal
+	t := r.tables()
+	query := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0) FROM %s WHERE asset = $1", t.BalanceCol, t.Wallets)
+	if err := r.readDB().QueryRowContext(ctx, query, supplyAsset).Scan(&supply); err != nil {
+		return "", err
+	}
+
+	return supply.StringFixed(18), nil
+}
+
+// Resolver for the transactions field
+func (r *queryResolver) Transactions(ctx context.Context, address string, direction *model.TransferDirection, from *time.Time, to *time.Time, first *int32, after *string) (*model.TransactionConnection, error) {
+	if r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	if err := validateEthereumAddress(address); err != nil {
+		return nil, err
+	}
+
+	filter := transactionFilter{address: normalizeAddress(address), direction: model.TransferDirectionAll, from: from, to: to}
+	if direction != nil {
+		filter.direction = *direction
+	}
+	return r.listTransactions(ctx, filter, first, after)
+}
+
+// Resolver for the balanceDelta field
+func (r *queryResolver) BalanceDelta(ctx context.Context, address string, from time.Time, to time.Time) (string, error) {
+	if r.TransactionTable == "" {
+		return "", fmt.Errorf("transaction history is disabled")
+	}
+
+	if err := validateEthereumAddress(address); err != nil {
+		return "", err
+	}
+	address = normalizeAddress(address)
+
+	if to.Before(from) {
+		return "", fmt.Errorf("invalid time range: from must not be after to")
+	}
+
+	// Credits count as positive, debits as negative
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(CASE WHEN to_address = $1 THEN amount ELSE -amount END), 0)
+		FROM %s
+		WHERE (from_address = $1 OR to_address = $1) AND created_at >= $2 AND created_at < $3 AND asset = $4`, r.TransactionTable)
+
+	var deltaStr string
+	if err := r.DB.QueryRowContext(ctx, query, address, from, to, r.baseAsset()).Scan(&deltaStr); err != nil {
+		return "", err
+	}
+
+	delta, err := decimal.NewFromString(deltaStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid balance delta format in DB")
+	}
+
+	return formatSigned(delta), nil
+}
+
+// Resolver for the reconcileWallet field
+func (r *queryResolver) ReconcileWallet(ctx context.Context, address string) (*model.WalletReconciliation, error) {
+	if r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	if err := validateEthereumAddress(address); err != nil {
+		return nil, err
+	}
+	address = normalizeAddress(address)
+
+	// Both values come from one snapshot, so a concurrent transfer cannot show up in only one of them
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.rollback(tx)
+
+	var storedStr string
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND asset = $2", t.BalanceCol, t.Wallets, t.AddressCol)
+	if err := tx.QueryRowContext(ctx, query, address, r.baseAsset()).Scan(&storedStr); err != nil {
+		return nil, err
+	}
+
+	// Credits minus debits, including mints, burns and the initial supply; a wallet without transactions is expected to hold 0
+	var computedStr string
+	query = fmt.Sprintf(`SELECT COALESCE(SUM(CASE WHEN to_address = $1 THEN amount ELSE -amount END), 0)
+		FROM %s
+		WHERE (from_address = $1 OR to_address = $1) AND asset = $2`, r.TransactionTable)
+	if err := tx.QueryRowContext(ctx, query, address, r.baseAsset()).Scan(&computedStr); err != nil {
+		return nil, err
+	}
+
+	stored, err := decimal.NewFromString(storedStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance format in DB")
+	}
+	computed, err := decimal.NewFromString(computedStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance format in DB")
+	}
+
+	return &model.WalletReconciliation{
+		Address:         address,
+		StoredBalance:   stored.StringFixed(18),
+		ComputedBalance: computed.StringFixed(18),
+		Consistent:      stored.Equal(computed),
+	}, nil
+}
+
+// Resolver for the negativeBalances field
+func (r *queryResolver) NegativeBalances(ctx context.Context) ([]*model.Wallet, error) {
+	t := r.tables()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s < 0 ORDER BY %s, asset", r.walletColumns(), t.Wallets, t.BalanceCol, t.AddressCol)
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []*model.Wallet{}
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// Resolver for the flowMatrix field
+func (r *queryResolver) FlowMatrix(ctx context.Context, from time.Time, to time.Time, topN *int32) ([]*model.FlowEdge, error) {
+	if r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("invalid time range: to must be after from")
+	}
+
+	// Default and cap number of returned edges
+	limit := int32(defaultFlowEdges)
+	if topN != nil {
+		limit = *topN
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("top_n must be greater than zero")
+	}
+	limit = min(limit, maxFlowEdges)
+
+	query := fmt.Sprintf(`SELECT from_address, to_address, SUM(amount) AS volume, COUNT(*)
+		FROM %s
+		WHERE created_at >= $1 AND created_at < $2 AND asset = $4
+		GROUP BY from_address, to_address
+		ORDER BY volume DESC, from_address, to_address
+		LIMIT $3`, r.TransactionTable)
+	rows, err := r.DB.QueryContext(ctx, query, from, to, limit, r.baseAsset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := []*model.FlowEdge{}
+	for rows.Next() {
+		var (
+			edge   model.FlowEdge
+			volume decimal.Decimal
+		)
+		if err := rows.Scan(&edge.FromAddress, &edge.ToAddress, &volume, &edge.Transfers); err != nil {
+			return nil, err
+		}
+		edge.Volume = volume.StringFixed(18)
+		edges = append(edges, &edge)
+	}
+
+	return edges, rows.Err()
+}
+
+// Resolver for the exportLedger field
+func (r *queryResolver) ExportLedger(ctx context.Context) (string, error) {
+	if !r.LedgerExportEnabled {
+		return "", fmt.Errorf("ledger export is disabled")
+	}
+	return r.exportLedger(ctx)
+}
+
+// Resolver for the transferRate field
+func (r *queryResolver) TransferRate(ctx context.Context, address string, window string) (*model.RateStats, error) {
+	if r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	if err := validateEthereumAddress(address); err != nil {
+		return nil, err
+	}
+	address = normalizeAddress(address)
+
+	length, ok := rateWindows[window]
+	if !ok {
+		return nil, fmt.Errorf("invalid window: must be one of minute, hour, day")
+	}
+	since := time.Now().Add(-length)
+
+	query := fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM %s
+		WHERE from_address = $1 AND created_at > $2 AND asset = $3`, r.TransactionTable)
+
+	stats := model.RateStats{Window: window}
+	var volume decimal.Decimal
+	if err := r.DB.QueryRowContext(ctx, query, address, since, r.baseAsset()).Scan(&stats.Transfers, &volume); err != nil {
+		return nil, err
+	}
+	stats.Volume = volume.StringFixed(18)
+
+	return &stats, nil
+}
+
+// Resolver for the verifyChain field
+func (r *queryResolver) VerifyChain(ctx context.Context) (*model.ChainVerification, error) {
+	if r.TransactionTable == "" {
+		return nil, fmt.Errorf("transaction history is disabled")
+	}
+
+	return r.verifyChain(ctx)
+}
+
+// Resolver for the allowance field
+func (r *queryResolver) Allowance(ctx context.Context, ownerAddress string, spenderAddress string) (string, error) {
+	if r.AllowanceTable == "" {
+		return "", fmt.Errorf("allowances are disabled")
+	}
+
+	// Same address checks as approve, for Go callers bypassing the Address scalar
+	if err := validateEthereumAddress(ownerAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", fmt.Errorf("ownerAddress invalid: %w", err)
+	}
+	if err := validateEthereumAddress(spenderAddress); err != nil {
+		r.recordValidationFailure(ctx, err)
+		return "", fmt.Errorf("spenderAddress invalid: %w", err)
+	}
+
+	// Read from the primary: a stale allowance would mislead a spender about to call transferFrom
+	var amount string
+	query := fmt.Sprintf("SELECT amount FROM %s WHERE owner_address = $1 AND spender_address = $2", r.AllowanceTable)
+	err := r.DB.QueryRowContext(ctx, query, normalizeAddress(ownerAddress), normalizeAddress(spenderAddress)).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "0", nil
+	}
+	return amount, err
+}
+
+// Resolver for the wouldSerialize field
+func (r *queryResolver) WouldSerialize(ctx context.Context, a string, b string, c string, d string) (bool, error) {
+	if !r.Debug {
+		return false, fmt.Errorf("debug queries are disabled")
+	}
+
+	a, b = normalizeAddress(a), normalizeAddress(b)
+	c, d = normalizeAddress(c), normalizeAddress(d)
+
+	// Logged transfers also share the chain head row, but only from their log insert to commit,
+	// so that short wait is not counted
+	if r.LockStrategy == LockRow || r.LockStrategy == LockOptimistic {
+		// Wallet rows are locked up front or by the balance updates, so only a shared wallet waits
+		return a == c || a == d || b == c || b == d, nil
+	}
+
+	// Base asset transfers contend if they share any advisory lock key, including hash collisions
+	asset := r.baseAsset()
+	for _, first := range lockKeys(r.lockName(a, asset), r.lockName(b, asset)) {
+		for _, second := range lockKeys(r.lockName(c, asset), r.lockName(d, asset)) {
+			if first == second {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Resolver for the balanceFormatted field
+func (r *walletResolver) BalanceFormatted(ctx context.Context, obj *model.Wallet, decimalSeparator *string, groupSeparator *string) (string, error) {
+	balance, err := decimal.NewFromString(obj.Balance)
+	if err != nil {
+		return "", fmt.Errorf("invalid balance format in DB")
+	}
+
+	decimalSep, groupSep := r.separators(decimalSeparator, groupSeparator)
+	if decimalSep == groupSep {
+		return "", fmt.Errorf("decimal and group separators must be different")
+	}
+
+	return formatDecimal(balance, decimalSep, groupSep), nil
+}
+
+// Resolver for the balanceChanged field
+func (r *subscriptionResolver) BalanceChanged(ctx context.Context, address string) (<-chan *model.BalanceChange, error) {
+	if err := validateEthereumAddress(address); err != nil {
+		return nil, err
+	}
+
+	// Channel is closed, ending the subscription, when the client disconnects
+	updates, unsubscribe := r.balances.subscribe(normalizeAddress(address))
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+
+	return updates, nil
+}
+
+// Mutation returns MutationResolver implementation
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Wallet returns WalletResolver implementation
+func (r *Resolver) Wallet() WalletResolver { return &walletResolver{r} }
 
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"token_transfer/graph/model"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ledger operations without GraphQL concerns, shared by the GraphQL resolvers, REST and gRPC
+// Settings, DB and in-process state (rate limits, batcher, subscriptions) come from the Resolver
+type Service struct {
+	*Resolver
+}
+
+func (r *Resolver) Service() *Service {
+	return &Service{r}
+}
+
+// Optional conditions and metadata of a transfer
+type TransferOptions struct {
+	ExpectedSenderBalance *string // transfer fails unless sender has exactly this balance
+	Memo                  *string // stored in the transaction log; requires TransactionTable
+	Spender               *string // transfer by spender, spending its allowance on the sender's wallet; requires AllowanceTable
+	Asset                 *string // asset to move; base asset when nil
+	TreasuryReason        *string // transfer out of the treasury, audited with this reason; requires TreasuryAddress and AuditTable
+}
+
+// Move amount between wallets with the same validation, limits and locking as the transfer mutation
+func (s *Service) Transfer(ctx context.Context, fromAddress, toAddress, amount string) (*model.TransferResult, error) {
+	result, _, err := s.TransferWithOptions(ctx, fromAddress, toAddress, amount, TransferOptions{})
+	return result, err
+}
+
+// Validate, move tokens and record the transfer
+// Receipt hash is returned separately, empty when history is disabled
+// Every call is written to the audit log, successful or not
+func (s *Service) TransferWithOptions(ctx context.Context, fromAddress, toAddress, amount string, opts TransferOptions) (transfer *model.TransferResult, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "Transfer", trace.WithAttributes(
+		attribute.String("transfer.from", fromAddress),
+		attribute.String("transfer.to", toAddress),
+		attribute.String("transfer.amount", amount),
+	))
+	start := time.Now()
+	asset := s.assetOrBase(opts.Asset)
+	defer func() {
+		endSpan(span, err)
+		observeTransfer(amount, time.Since(start), err)
+		s.auditTransfer(ctx, fromAddress, toAddress, asset, amount, transfer, err)
+	}()
+
+	// Treasury distributions are audited, so they need the audit table and a reason
+	if opts.TreasuryReason != nil {
+		if s.TreasuryAddress == "" || s.AuditTable == "" {
+			return nil, "", fmt.Errorf("treasury is not configured")
+		}
+		if strings.TrimSpace(*opts.TreasuryReason) == "" {
+			err := &validationError{"missing_reason", "reason is required"}
+			s.recordValidationFailure(ctx, err)
+			return nil, "", err
+		}
+	}
+
+	// Validate addressess and amount
+	if err := validateTransferInput(fromAddress, toAddress, amount); err != nil {
+		s.recordValidationFailure(ctx, err)
+		return nil, "", err
+	}
+	if err := s.checkAmountLimits(amount); err != nil {
+		s.recordValidationFailure(ctx, err)
+		return nil, "", err
+	}
+	fromAddress, toAddress = normalizeAddress(fromAddress), normalizeAddress(toAddress)
+	if err := validateAsset(asset); err != nil {
+		s.recordValidationFailure(ctx, err)
+		return nil, "", err
+	}
+
+	// Delegated transfer spends the allowance of spender on the sender's wallet
+	spender := ""
+	if opts.Spender != nil {
+		if s.AllowanceTable == "" {
+			return nil, "", fmt.Errorf("allowances are disabled")
+		}
+		if asset != s.baseAsset() {
+			return nil, "", fmt.Errorf("allowances cover only the base asset")
+		}
+		if err := validateEthereumAddress(*opts.Spender); err != nil {
+			s.recordValidationFailure(ctx, err)
+			return nil, "", fmt.Errorf("spenderAddress invalid: %w", err)
+		}
+		spender = normalizeAddress(*opts.Spender)
+	}
+
+	// Audit entries record base asset amounts out of the treasury
+	if opts.TreasuryReason != nil {
+		if !s.isTreasury(fromAddress) {
+			return nil, "", fmt.Errorf("treasury transfers must come from the treasury")
+		}
+		if asset != s.baseAsset() {
+			return nil, "", fmt.Errorf("treasury transfers cover only the base asset")
+		}
+	}
+
+	// Cap transfers per sender to prevent abuse; treasury distributions are audited instead
+	if s.TransferRateLimit > 0 && opts.TreasuryReason == nil && !s.rateLimiter.allow(fromAddress, s.TransferRateLimit, time.Now()) {
+		return nil, "", ErrRateLimitExceeded
+	}
+
+	// Memo is kept only in the transaction log
+	transferMemo := ""
+	if opts.Memo != nil {
+		if s.TransactionTable == "" {
+			return nil, "", fmt.Errorf("transaction history is disabled")
+		}
+		if err := validateMemo(*opts.Memo); err != nil {
+			s.recordValidationFailure(ctx, err)
+			return nil, "", err
+		}
+		transferMemo = *opts.Memo
+	}
+
+	// Protected treasury can be spent only with a reason
+	if s.TreasuryProtected && s.isTreasury(fromAddress) && opts.TreasuryReason == nil {
+		return nil, "", fmt.Errorf("transfers from treasury require treasuryTransfer")
+	}
+
+	// Send-only treasury is a source of tokens, never a recipient
+	if s.TreasurySendOnly && s.isTreasury(toAddress) {
+		return nil, "", fmt.Errorf("transfers to treasury are disabled")
+	}
+
+	// Transfer must not wait for locks longer than TransferTimeout
+	if s.TransferTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.TransferTimeout)
+		defer cancel()
+	}
+
+	// In batching mode transfers are committed together by the batcher; delegated and treasury ones run on their own
+	if s.BatchWindow > 0 && spender == "" && opts.TreasuryReason == nil {
+		result, err := s.batchedTransfer(ctx, fromAddress, toAddress, asset, amount, opts.ExpectedSenderBalance, transferMemo)
+		if err != nil {
+			return nil, "", err
+		}
+		s.publishBalances(fromAddress, toAddress, result)
+		transfer = newTransferResult(fromAddress, toAddress, amount, result)
+		s.notifyWebhook(transfer)
+		return transfer, result.receipt, nil
+	}
+
+	var result transferResult
+	err = s.runTransferTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = s.transferInTx(ctx, tx, fromAddress, toAddress, asset, amount, opts.ExpectedSenderBalance, transferMemo)
+		if err != nil {
+			return err
+		}
+		// Audit entry is committed together with the transfer
+		if opts.TreasuryReason != nil {
+			if err := s.recordTreasuryAudit(ctx, tx, toAddress, amount, *opts.TreasuryReason); err != nil {
+				return err
+			}
+		}
+		if spender == "" {
+			return nil
+		}
+		// After the wallet locks, so the allowance row is always locked last
+		return s.spendAllowance(ctx, tx, fromAddress, spender, amount)
+	})
+	if err != nil {
+		return nil, "", transferTimeoutError(ctx, err)
+	}
+
+	s.publishBalances(fromAddress, toAddress, result)
+	transfer = newTransferResult(fromAddress, toAddress, amount, result)
+	s.notifyWebhook(transfer)
+
+	return transfer, result.receipt, nil
+}
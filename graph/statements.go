@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Statements of the transfer path, prepared once by Prepare
+// A nil statement means the same SQL is sent inline
+type preparedStatements struct {
+	transferWallets *sql.Stmt
+	tokenBalance    *sql.Stmt
+	debit           *sql.Stmt
+	credit          *sql.Stmt
+	addWallet       *sql.Stmt
+}
+
+// Prepare hot transfer queries once, so they are not parsed on every call
+// Call at startup after Validate; on error nothing is prepared and transfers keep using inline SQL
+// Prepared statements live on a DB session, so nothing is prepared with PoolerCompatible
+func (r *Resolver) Prepare(ctx context.Context) error {
+	if r.PoolerCompatible {
+		return nil
+	}
+
+	var statements preparedStatements
+	var err error
+	prepare := func(query string) *sql.Stmt {
+		if err != nil {
+			return nil
+		}
+		var stmt *sql.Stmt
+		stmt, err = r.DB.PrepareContext(ctx, query)
+		return stmt
+	}
+
+	statements.transferWallets = prepare(r.transferWalletsQuery())
+	statements.tokenBalance = prepare(r.tokenBalanceQuery())
+	statements.debit = prepare(r.debitQuery())
+	statements.credit = prepare(r.creditQuery())
+	statements.addWallet = prepare(r.addWalletQuery())
+	if err != nil {
+		statements.close()
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	r.statements = statements
+	return nil
+}
+
+// Close prepared statements, if any
+func (r *Resolver) Close() error {
+	err := r.statements.close()
+	r.statements = preparedStatements{}
+	return err
+}
+
+func (s *preparedStatements) close() error {
+	var errs []error
+	for _, stmt := range []*sql.Stmt{s.transferWallets, s.tokenBalance, s.debit, s.credit, s.addWallet} {
+		if stmt != nil {
+			errs = append(errs, stmt.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run prepared statement inside tx, or query inline when it is not prepared
+func txExec(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, query string, args ...any) (sql.Result, error) {
+	if stmt != nil {
+		return tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
+
+func txQuery(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, query string, args ...any) (*sql.Rows, error) {
+	if stmt != nil {
+		return tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	}
+	return tx.QueryContext(ctx, query, args...)
+}
+
+func txQueryRow(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, query string, args ...any) *sql.Row {
+	if stmt != nil {
+		return tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	}
+	return tx.QueryRowContext(ctx, query, args...)
+}
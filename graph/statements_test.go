@@ -0,0 +1,20 @@
+package graph
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrepareSkippedWhenPoolerCompatible(t *testing.T) {
+	// No DB: preparing anything would panic
+	r := &Resolver{WalletTable: "wallets", PoolerCompatible: true}
+	if err := r.Prepare(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if r.statements != (preparedStatements{}) {
+		t.Errorf("Expected no prepared statements, got %+v", r.statements)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Expected no error on close, got: %v", err)
+	}
+}
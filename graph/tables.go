@@ -0,0 +1,52 @@
+package graph
+
+import "fmt"
+
+// Names of the wallet table and of its address and balance columns, for integrating with an existing schema
+// Empty fields keep the defaults: WalletTable, "address" and "token_balance"
+// The other wallet columns (asset, owner_id, frozen, version) are not configurable
+type TableConfig struct {
+	Wallets    string
+	AddressCol string
+	BalanceCol string
+}
+
+// Default column names of the wallet table
+const (
+	defaultAddressCol = "address"
+	defaultBalanceCol = "token_balance"
+)
+
+// Configured names with defaults filled in
+func (r *Resolver) tables() TableConfig {
+	tables := r.Tables
+	if tables.Wallets == "" {
+		tables.Wallets = r.WalletTable
+	}
+	if tables.AddressCol == "" {
+		tables.AddressCol = defaultAddressCol
+	}
+	if tables.BalanceCol == "" {
+		tables.BalanceCol = defaultBalanceCol
+	}
+	return tables
+}
+
+// Columns read into model.Wallet, in scanWallet order
+func (r *Resolver) walletColumns() string {
+	t := r.tables()
+	return fmt.Sprintf("%s, asset, %s, owner_id, frozen", t.AddressCol, t.BalanceCol)
+}
+
+// Check configured names are plain SQL identifiers
+func (t TableConfig) validate() error {
+	if err := validateIdentifier(t.Wallets); err != nil {
+		return fmt.Errorf("invalid wallet table name: %w", err)
+	}
+	for _, column := range []string{t.AddressCol, t.BalanceCol} {
+		if err := validateIdentifier(column); err != nil {
+			return fmt.Errorf("invalid wallet column name: %w", err)
+		}
+	}
+	return nil
+}
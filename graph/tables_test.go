@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableConfigDefaults(t *testing.T) {
+	r := &Resolver{WalletTable: "wallets"}
+	if tables := r.tables(); tables != (TableConfig{Wallets: "wallets", AddressCol: "address", BalanceCol: "token_balance"}) {
+		t.Errorf("Unexpected default tables: %+v", tables)
+	}
+	if columns := r.walletColumns(); columns != "address, asset, token_balance, owner_id, frozen" {
+		t.Errorf("Unexpected default wallet columns: %s", columns)
+	}
+
+	// Tables.Wallets takes precedence over WalletTable
+	r.Tables = TableConfig{Wallets: "accounts"}
+	if tables := r.tables(); tables.Wallets != "accounts" || tables.AddressCol != "address" {
+		t.Errorf("Unexpected tables: %+v", tables)
+	}
+}
+
+func TestTableConfigQueries(t *testing.T) {
+	r := &Resolver{Tables: TableConfig{Wallets: "accounts", AddressCol: "account_address", BalanceCol: "amount"}}
+
+	// Configured names replace the defaults in every wallet query
+	for _, query := range []string{r.tokenBalanceQuery(), r.transferWalletsQuery(), r.creditQuery(), r.debitQuery(), r.addWalletQuery()} {
+		if !strings.Contains(query, "accounts") || !strings.Contains(query, "account_address") || !strings.Contains(query, "amount") {
+			t.Errorf("Expected configured names in %q", query)
+		}
+		if strings.Contains(query, "token_balance") || strings.Contains(query, " address") {
+			t.Errorf("Expected no default column names in %q", query)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestApproveAndTransferFrom(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:             db,
+		WalletTable:    "test_wallets",
+		AllowanceTable: "test_allowances",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	owner := "0xa000000000000000000000000000000000000000"
+	spender := "0xb000000000000000000000000000000000000000"
+	recipient := "0xc000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearAllowances(t, db)
+	initWallet(t, db, owner, "100")
+
+	// No allowance before approve
+	allowance, err := query.Allowance(ctx, owner, spender)
+	if err != nil {
+		t.Fatalf("Allowance failed: %v", err)
+	}
+	if allowance != "0" {
+		t.Errorf("Expected zero allowance, got %s", allowance)
+	}
+
+	approved, err := mutation.Approve(ctx, owner, spender, "30")
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if approved.Amount != "30.000000000000000000" {
+		t.Errorf("Expected approved amount 30, got %s", approved.Amount)
+	}
+
+	// Spender moves tokens from owner's wallet
+	result, err := mutation.TransferFrom(ctx, spender, owner, recipient, "20")
+	if err != nil {
+		t.Fatalf("TransferFrom failed: %v", err)
+	}
+	if result.SenderBalance != "80.000000000000000000" {
+		t.Errorf("Expected sender balance 80, got %s", result.SenderBalance)
+	}
+	assertBalance(t, db, "80", owner)
+	assertBalance(t, db, "20", recipient)
+
+	// Mixed-case addresses read the same allowance
+	allowance, err = query.Allowance(ctx, "0xA000000000000000000000000000000000000000", "0xB000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("Allowance failed: %v", err)
+	}
+	if allowance != "10.000000000000000000" {
+		t.Errorf("Expected remaining allowance 10, got %s", allowance)
+	}
+
+	// Spending more than the remaining allowance fails without moving tokens
+	_, err = mutation.TransferFrom(ctx, spender, owner, recipient, "15")
+	if !errors.Is(err, graph.ErrInsufficientAllowance) {
+		t.Fatalf("Expected ErrInsufficientAllowance, got %v", err)
+	}
+	assertBalance(t, db, "80", owner)
+	assertBalance(t, db, "20", recipient)
+
+	// Failed transfer does not spend the allowance
+	if _, err := mutation.Approve(ctx, owner, spender, "500"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	_, err = mutation.TransferFrom(ctx, spender, owner, recipient, "200")
+	if !errors.Is(err, graph.ErrInsufficientBalance) {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+	allowance, _ = query.Allowance(ctx, owner, spender)
+	if allowance != "500.000000000000000000" {
+		t.Errorf("Expected allowance 500 after failed transfer, got %s", allowance)
+	}
+
+	// Approving zero revokes the allowance
+	if _, err := mutation.Approve(ctx, owner, spender, "0"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	_, err = mutation.TransferFrom(ctx, spender, owner, recipient, "1")
+	if !errors.Is(err, graph.ErrInsufficientAllowance) {
+		t.Fatalf("Expected ErrInsufficientAllowance after revoke, got %v", err)
+	}
+}
+
+func TestConcurrentTransferFromDoesNotOverspend(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:             db,
+		WalletTable:    "test_wallets",
+		AllowanceTable: "test_allowances",
+	}
+
+	mutation := resolver.Mutation()
+
+	owner := "0xa000000000000000000000000000000000000000"
+	spender := "0xb000000000000000000000000000000000000000"
+	recipient := "0xc000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearAllowances(t, db)
+	initWallet(t, db, owner, "100")
+	if _, err := mutation.Approve(ctx, owner, spender, "5"); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+
+	// 10 transfers of 1 against an allowance of 5
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mutation.TransferFrom(ctx, spender, owner, recipient, "1")
+			if err != nil && !errors.Is(err, graph.ErrInsufficientAllowance) {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 5 {
+		t.Errorf("Expected 5 successful transfers, got %d", succeeded)
+	}
+	assertBalance(t, db, "95", owner)
+	assertBalance(t, db, "5", recipient)
+}
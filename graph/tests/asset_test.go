@@ -0,0 +1,120 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestTransferPerAsset(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+		MintEnabled:      true,
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	usdc := "USDC"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Minting another asset creates a separate wallet row for the same address
+	balance, err := mutation.Mint(ctx, aAddress, "50", &usdc)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if balance != "50.000000000000000000" {
+		t.Errorf("Expected USDC balance 50.000000000000000000, got %s", balance)
+	}
+
+	result, err := mutation.Transfer(ctx, aAddress, bAddress, "20", nil, &usdc)
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if result.Asset != "USDC" || result.SenderBalance != "30.000000000000000000" || result.RecipientBalance != "20.000000000000000000" {
+		t.Errorf("Unexpected transfer result: %+v", result)
+	}
+
+	// Base asset balances are untouched
+	assertBalance(t, db, "10", aAddress)
+	if wallet, err := query.Wallet(ctx, bAddress, nil); err == nil {
+		t.Errorf("Expected no base asset wallet for recipient, got %+v", wallet)
+	}
+
+	wallet, err := query.Wallet(ctx, bAddress, &usdc)
+	if err != nil {
+		t.Fatalf("Expected USDC wallet, got: %v", err)
+	}
+	if wallet.Asset != "USDC" || wallet.Balance != "20.000000000000000000" {
+		t.Errorf("Unexpected USDC wallet: %+v", wallet)
+	}
+
+	// Supply is per asset
+	supply, err := query.TotalSupply(ctx, &usdc)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if supply != "50.000000000000000000" {
+		t.Errorf("Expected USDC supply 50.000000000000000000, got %s", supply)
+	}
+	supply, err = query.TotalSupply(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if supply != "10.000000000000000000" {
+		t.Errorf("Expected base supply 10.000000000000000000, got %s", supply)
+	}
+
+	// Insufficient balance is checked against the transferred asset only
+	_, err = mutation.Transfer(ctx, bAddress, aAddress, "21", nil, &usdc)
+	if err == nil || !strings.Contains(err.Error(), "insufficient balance") {
+		t.Errorf("Expected 'insufficient balance' error, got: %v", err)
+	}
+
+	// A base asset transfer still works and the mixed chain verifies
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+	chain, err := query.VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !chain.Valid || chain.Checked != 2 {
+		t.Errorf("Expected valid chain of 2 transactions, got %+v", chain)
+	}
+}
+
+func TestTransferRejectsInvalidAsset(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	invalid := "usd coin"
+
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	_, err := resolver.Mutation().Transfer(ctx, aAddress, bAddress, "1", nil, &invalid)
+	if err == nil || !strings.Contains(err.Error(), "invalid asset") {
+		t.Errorf("Expected 'invalid asset' error, got: %v", err)
+	}
+	assertBalance(t, db, "10", aAddress)
+}
@@ -0,0 +1,62 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestTransferAuditLog(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	// Capture audit log output
+	var logs bytes.Buffer
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+		AuditLogger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// One line per transfer, without a transaction table
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "4")
+	if _, err := mutation.Transfer(ctx, aAddress, bAddress, "100", nil, nil); err == nil {
+		t.Fatal("Expected insufficient balance")
+	}
+
+	var entries []map[string]any
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var entry map[string]any
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("Invalid audit log line: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit log lines, got %d: %s", len(entries), logs.String())
+	}
+
+	if entries[0]["msg"] != "transfer" || entries[0]["from"] != aAddress || entries[0]["to"] != bAddress ||
+		entries[0]["amount"] != "4.000000000000000000" || entries[0]["new_sender_balance"] != "6.000000000000000000" || entries[0]["timestamp"] == nil {
+		t.Errorf("Unexpected audit log line for transfer: %v", entries[0])
+	}
+	if entries[1]["msg"] != "transfer failed" || entries[1]["reason"] != "insufficient_balance" {
+		t.Errorf("Unexpected audit log line for failed transfer: %v", entries[1])
+	}
+}
@@ -0,0 +1,220 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Seed wallets, run the same set of concurrent transfers and return final balances
+func runConcurrentScenario(t *testing.T, resolver *graph.Resolver, addresses []string) []string {
+	t.Helper()
+	db := resolver.DB
+	ctx := context.Background()
+	mutation := resolver.Mutation()
+
+	aAddress, bAddress, cAddress := addresses[0], addresses[1], addresses[2]
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+	initWallet(t, db, bAddress, "1000")
+	initWallet(t, db, cAddress, "1")
+
+	const transferCount = 20
+	var wg sync.WaitGroup
+	wg.Add(transferCount + 1)
+
+	// Synchronization barrier
+	start := make(chan struct{})
+
+	// 10 transfers A -> B (amount 5), 10 transfers B -> A (amount 10)
+	for i := 0; i < transferCount; i++ {
+		fromAddress, toAddress, amount := aAddress, bAddress, "5"
+		if i%2 == 1 {
+			fromAddress, toAddress, amount = bAddress, aAddress, "10"
+		}
+
+		go func(from, to, amount string) {
+			defer wg.Done()
+			<-start // barrier up
+			doTransfer(t, mutation, ctx, from, to, amount)
+		}(fromAddress, toAddress, amount)
+	}
+
+	// C -> A always fails, and must not affect other transfers
+	go func() {
+		defer wg.Done()
+		<-start // barrier up
+		_, err := mutation.Transfer(ctx, cAddress, aAddress, "5", nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "insufficient balance") {
+			t.Errorf("Expected 'insufficient balance' error for C -> A, got: %v", err)
+		}
+	}()
+
+	close(start) // bariers down
+	wg.Wait()
+
+	balances := make([]string, len(addresses))
+	for i, address := range addresses {
+		balances[i] = getBalance(t, db, address)
+	}
+	return balances
+}
+
+func TestBatchedTransfersMatchUnbatched(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	addresses := []string{
+		"0xA000000000000000000000000000000000000000",
+		"0xB000000000000000000000000000000000000000",
+		"0xC000000000000000000000000000000000000000",
+	}
+
+	unbatched := runConcurrentScenario(t, &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}, addresses)
+
+	batched := runConcurrentScenario(t, &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+		BatchWindow: 5 * time.Millisecond,
+	}, addresses)
+
+	// Check if both modes end with identical balances
+	for i, address := range addresses {
+		if !decimal.RequireFromString(batched[i]).Equal(decimal.RequireFromString(unbatched[i])) {
+			t.Errorf("Balance of %s differs: batched %s, unbatched %s", address, batched[i], unbatched[i])
+		}
+	}
+
+	// Expected:
+	// A = 1000 - 10 × 5 + 10 × 10 = 1050
+	// B = 1000 + 10 × 5 - 10 × 10 = 950
+	// C = 1 (its transfer failed)
+	assertBalance(t, db, "1050", addresses[0])
+	assertBalance(t, db, "950", addresses[1])
+	assertBalance(t, db, "1", addresses[2])
+}
+
+func BenchmarkTransferBatching(b *testing.B) {
+	db := testutils.SetupDB(b)
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	modes := []struct {
+		name   string
+		window time.Duration
+	}{
+		{"unbatched", 0},
+		{"batched", 2 * time.Millisecond},
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			resolver := &graph.Resolver{
+				DB:          db,
+				WalletTable: "test_wallets",
+				BatchWindow: mode.window,
+			}
+			mutation := resolver.Mutation()
+			ctx := context.Background()
+
+			// Clean and seed test data
+			clearWallets(b, db)
+			initWallet(b, db, aAddress, "1000000")
+			initWallet(b, db, bAddress, "1000000")
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				forward := true
+				for pb.Next() {
+					from, to := aAddress, bAddress
+					if !forward {
+						from, to = bAddress, aAddress
+					}
+					forward = !forward
+
+					if _, err := mutation.Transfer(ctx, from, to, "0.000000000000000001", nil, nil); err != nil {
+						b.Errorf("Transfer %s → %s failed: %v", from, to, err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestBatchedTransfersWithHistory(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	cAddress := "0xc000000000000000000000000000000000000000"
+	dAddress := "0xd000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	for _, address := range []string{aAddress, bAddress, cAddress, dAddress} {
+		initWallet(t, db, address, "1000")
+	}
+
+	batched := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+		BatchWindow:      5 * time.Millisecond,
+	}
+	unbatched := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	// Batches lock every wallet before the chain head, so they run alongside single transfers
+	// of the same wallets in both directions without deadlocking
+	const transferCount = 20
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < transferCount; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			doTransfer(t, batched.Mutation(), ctx, aAddress, bAddress, "1")
+			doTransfer(t, batched.Mutation(), ctx, dAddress, cAddress, "1")
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			doTransfer(t, unbatched.Mutation(), ctx, cAddress, dAddress, "2")
+			doTransfer(t, unbatched.Mutation(), ctx, bAddress, aAddress, "2")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assertBalance(t, db, "1020", aAddress)
+	assertBalance(t, db, "980", bAddress)
+	assertBalance(t, db, "980", cAddress)
+	assertBalance(t, db, "1020", dAddress)
+
+	// Every transfer is in the chain exactly once
+	result, err := unbatched.Query().VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !result.Valid || result.Checked != 4*transferCount {
+		t.Errorf("Expected valid chain of %d transactions, got %+v", 4*transferCount, result)
+	}
+}
@@ -0,0 +1,71 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+const (
+	benchSender    = "0xa000000000000000000000000000000000000000"
+	benchRecipient = "0xb000000000000000000000000000000000000000"
+)
+
+// Resolver over two freshly seeded wallets; called at the start of every run, so runs do not share state
+func seedBenchmark(b *testing.B) graph.MutationResolver {
+	b.Helper()
+
+	db := testutils.SetupDB(b)
+	clearWallets(b, db)
+	initWallet(b, db, benchSender, "1000000")
+	initWallet(b, db, benchRecipient, "1000000")
+
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+	return resolver.Mutation()
+}
+
+// Sequential transfers back and forth between two wallets
+func BenchmarkTransfer(b *testing.B) {
+	mutation := seedBenchmark(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		from, to := benchSender, benchRecipient
+		if i%2 == 1 {
+			from, to = benchRecipient, benchSender
+		}
+		if _, err := mutation.Transfer(ctx, from, to, "1", nil, nil); err != nil && !errors.Is(err, graph.ErrInsufficientBalance) {
+			b.Fatalf("Transfer %s → %s failed: %v", from, to, err)
+		}
+	}
+}
+
+// Parallel transfers contending for the same two wallets
+// Insufficient balance is an expected outcome under contention and does not fail the run
+func BenchmarkConcurrentTransfers(b *testing.B) {
+	mutation := seedBenchmark(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		forward := true
+		for pb.Next() {
+			from, to := benchSender, benchRecipient
+			if !forward {
+				from, to = benchRecipient, benchSender
+			}
+			forward = !forward
+
+			if _, err := mutation.Transfer(ctx, from, to, "1", nil, nil); err != nil && !errors.Is(err, graph.ErrInsufficientBalance) {
+				b.Errorf("Transfer %s → %s failed: %v", from, to, err)
+			}
+		}
+	})
+}
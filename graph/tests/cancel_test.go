@@ -0,0 +1,116 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+// Advisory lock key of a wallet, as computed by the resolver
+func walletLockKey(address string) int64 {
+	h := fnv.New64()
+	h.Write([]byte(address))
+	return int64(h.Sum64())
+}
+
+func TestTransferCancelledWhileWaitingForLock(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Another transaction holds the lock of the sender
+	holder, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := holder.Exec("SELECT pg_advisory_xact_lock($1)", walletLockKey(aAddress)); err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	// Transfer gives up when its deadline passes
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = mutation.Transfer(ctx, aAddress, bAddress, "1", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer waiting for lock was not cancelled")
+	}
+	// Check it failed because of the deadline, not for another reason
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("Expected transfer to wait until deadline, got: %v", err)
+	}
+
+	if err := holder.Rollback(); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+
+	// Cancelled transfer released its locks: the next one goes through right away
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+
+	assertBalance(t, db, "9", aAddress)
+	assertBalance(t, db, "1", bAddress)
+}
+
+func TestTransferTimeout(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	resolver := &graph.Resolver{
+		DB:              db,
+		WalletTable:     "test_wallets",
+		TransferTimeout: 200 * time.Millisecond,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Another transaction holds the lock of the recipient
+	holder, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer holder.Rollback()
+	if _, err := holder.Exec("SELECT pg_advisory_xact_lock($1)", walletLockKey(bAddress)); err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	start := time.Now()
+	_, err = mutation.Transfer(context.Background(), aAddress, bAddress, "1", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer waiting for lock did not time out")
+	}
+	// Check error type
+	if !errors.Is(err, graph.ErrTransferTimeout) {
+		t.Fatalf("Expected 'transfer timed out' error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected transfer to give up after about 200ms, took %s", elapsed)
+	}
+
+	assertBalance(t, db, "10", aAddress)
+}
@@ -0,0 +1,114 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestVerifyChain(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Build chain of 3 transactions
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+	doTransfer(t, mutation, ctx, bAddress, aAddress, "0.5")
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "0.000000000000000001")
+
+	result, err := query.VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Check if chain is valid
+	if !result.Valid {
+		t.Fatalf("Expected valid chain, broken at %v", *result.BrokenAt)
+	}
+	if result.Checked != 3 {
+		t.Errorf("Expected 3 checked transactions, got %d", result.Checked)
+	}
+
+	// Chain head points at the last transaction
+	var head, last string
+	if err := db.QueryRow("SELECT hash FROM test_transactions_head").Scan(&head); err != nil {
+		t.Fatalf("Failed to read chain head: %v", err)
+	}
+	if err := db.QueryRow("SELECT hash FROM test_transactions ORDER BY id DESC LIMIT 1").Scan(&last); err != nil {
+		t.Fatalf("Failed to read last transaction: %v", err)
+	}
+	if head != last {
+		t.Errorf("Expected chain head %s, got %s", last, head)
+	}
+}
+
+func TestVerifyChain_Tampered(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "200")
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "300")
+
+	// Tamper with amount of the second transaction
+	var tamperedID string
+	err := db.QueryRow(`
+		UPDATE test_transactions SET amount = 2
+		WHERE id = (SELECT id FROM test_transactions ORDER BY id LIMIT 1 OFFSET 1)
+		RETURNING id
+	`).Scan(&tamperedID)
+	if err != nil {
+		t.Fatalf("Failed to tamper with transaction: %v", err)
+	}
+
+	result, err := query.VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Check if tampering was detected at the modified transaction
+	if result.Valid {
+		t.Fatal("Expected tampered chain to be invalid")
+	}
+	if result.BrokenAt == nil || *result.BrokenAt != tamperedID {
+		t.Errorf("Expected chain broken at %s, got %v", tamperedID, result.BrokenAt)
+	}
+	if result.Checked != 1 {
+		t.Errorf("Expected 1 valid transaction before the break, got %d", result.Checked)
+	}
+}
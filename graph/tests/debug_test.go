@@ -0,0 +1,153 @@
+package graph_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestWouldSerialize(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+		Debug:       true,
+	}
+
+	qr := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+	dAddress := "0xD000000000000000000000000000000000000000"
+
+	cases := []struct {
+		name       string
+		a, b, c, d string
+		expected   bool
+	}{
+		{"shared wallet", aAddress, bAddress, bAddress, cAddress, true},
+		{"opposite directions", aAddress, bAddress, bAddress, aAddress, true},
+		{"disjoint wallets", aAddress, bAddress, cAddress, dAddress, false},
+	}
+
+	for _, c := range cases {
+		contend, err := qr.WouldSerialize(ctx, c.a, c.b, c.c, c.d)
+		if err != nil {
+			t.Fatalf("%s: expected no error but got: %v", c.name, err)
+		}
+		if contend != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, contend)
+		}
+	}
+
+	// Chain head lock of the transaction log is held only until commit and is not counted
+	resolver.TransactionTable = "test_transactions"
+	contend, err := qr.WouldSerialize(ctx, aAddress, bAddress, cAddress, dAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if contend {
+		t.Error("Expected disjoint transfers not to contend with the transaction log enabled")
+	}
+}
+
+func TestWouldSerialize_DebugDisabled(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	qr := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	_, err := qr.WouldSerialize(ctx, aAddress, bAddress, bAddress, aAddress)
+	// Check if query throws error
+	if err == nil {
+		t.Fatal("Debug query did not throw error with debug disabled")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "debug queries are disabled") {
+		t.Fatalf("Expected 'debug queries are disabled' error, got: %v", err)
+	}
+}
+
+func TestLockOrderLogging(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	// Capture log output
+	var logs bytes.Buffer
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:           db,
+		WalletTable:  "test_wallets",
+		Logger:       slog.New(slog.NewJSONHandler(&logs, nil)),
+		LogLockOrder: true,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+	initWallet(t, db, bAddress, "10")
+
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+
+	// Expected order: lowercase address with smaller FNV-64 hash is locked first
+	hash := func(address string) int64 {
+		h := fnv.New64()
+		h.Write([]byte(strings.ToLower(address)))
+		return int64(h.Sum64())
+	}
+	expectedOrder := []string{aAddress, bAddress}
+	if hash(bAddress) < hash(aAddress) {
+		expectedOrder = []string{bAddress, aAddress}
+	}
+
+	// Find lock log line
+	var entry struct {
+		Msg       string   `json:"msg"`
+		LockOrder []string `json:"lock_order"`
+		LockKeys  []int64  `json:"lock_keys"`
+	}
+	found := false
+	scanner := bufio.NewScanner(&logs)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", scanner.Text(), err)
+		}
+		if entry.Msg == "advisory locks acquired" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatalf("Expected lock order log line, got: %s", logs.String())
+	}
+
+	// Check logged order of addresses and keys
+	if len(entry.LockOrder) != 2 || entry.LockOrder[0] != expectedOrder[0] || entry.LockOrder[1] != expectedOrder[1] {
+		t.Errorf("Expected lock order %v, got %v", expectedOrder, entry.LockOrder)
+	}
+	if len(entry.LockKeys) != 2 || entry.LockKeys[0] != hash(expectedOrder[0]) || entry.LockKeys[1] != hash(expectedOrder[1]) {
+		t.Errorf("Expected lock keys [%d %d], got %v", hash(expectedOrder[0]), hash(expectedOrder[1]), entry.LockKeys)
+	}
+}
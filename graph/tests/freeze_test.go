@@ -0,0 +1,113 @@
+package graph_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestFreezeWallet(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	cAddress := "0xc000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+	initWallet(t, db, bAddress, "100")
+	initWallet(t, db, cAddress, "100")
+
+	// Mixed-case address is normalized
+	wallet, err := mutation.FreezeWallet(ctx, "0xA000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+	if !wallet.Frozen || wallet.Address != aAddress {
+		t.Errorf("Expected frozen wallet %s, got %+v", aAddress, wallet)
+	}
+
+	// Frozen wallet can neither send nor receive
+	if _, err := mutation.Transfer(ctx, aAddress, bAddress, "1", nil, nil); !errors.Is(err, graph.ErrWalletFrozen) {
+		t.Errorf("Expected ErrWalletFrozen for frozen sender, got: %v", err)
+	}
+	if _, err := mutation.Transfer(ctx, cAddress, aAddress, "1", nil, nil); !errors.Is(err, graph.ErrWalletFrozen) {
+		t.Errorf("Expected ErrWalletFrozen for frozen recipient, got: %v", err)
+	}
+	assertBalance(t, db, "100", aAddress)
+	assertBalance(t, db, "100", cAddress)
+
+	// Other wallets are not affected
+	doTransfer(t, mutation, ctx, bAddress, cAddress, "1")
+
+	// Unfrozen wallet transfers again
+	wallet, err = mutation.UnfreezeWallet(ctx, aAddress)
+	if err != nil {
+		t.Fatalf("Unfreeze failed: %v", err)
+	}
+	if wallet.Frozen {
+		t.Errorf("Expected unfrozen wallet, got %+v", wallet)
+	}
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+	assertBalance(t, db, "99", aAddress)
+
+	// Unknown wallet
+	if _, err := mutation.FreezeWallet(ctx, "0xd000000000000000000000000000000000000000"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for unknown wallet, got: %v", err)
+	}
+}
+
+func TestFreezeWaitsForTransferLock(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+
+	// Row lock held like a transfer in progress
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SELECT 1 FROM test_wallets WHERE address = $1 FOR UPDATE", aAddress); err != nil {
+		t.Fatalf("Failed to lock wallet: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := resolver.Mutation().FreezeWallet(ctx, aAddress)
+		done <- err
+	}()
+
+	// Freeze completes only after the lock is released
+	select {
+	case err := <-done:
+		t.Fatalf("Freeze did not wait for the row lock: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	tx.Commit()
+	if err := <-done; err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package graph_test
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 
 	"token_transfer/graph"
@@ -10,15 +11,16 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-func initWallet(t *testing.T, db *sql.DB, address string, balance string) {
+// Wallets are keyed by the lowercase address the resolvers store, whatever case tests use
+func initWallet(t testing.TB, db *sql.DB, address string, balance string) {
 	t.Helper()
-	_, err := db.Exec("INSERT INTO test_wallets (address, token_balance) VALUES ($1, $2::numeric)", address, balance)
+	_, err := db.Exec("INSERT INTO test_wallets (address, token_balance) VALUES ($1, $2::numeric)", strings.ToLower(address), balance)
 	if err != nil {
 		t.Fatalf("Failed to insert wallet %s: %v", address, err)
 	}
 }
 
-func clearWallets(t *testing.T, db *sql.DB) {
+func clearWallets(t testing.TB, db *sql.DB) {
 	t.Helper()
 	_, err := db.Exec("DELETE FROM test_wallets")
 	if err != nil {
@@ -26,10 +28,31 @@ func clearWallets(t *testing.T, db *sql.DB) {
 	}
 }
 
-func getBalance(t *testing.T, db *sql.DB, address string) string {
+func clearTransactions(t testing.TB, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec("DELETE FROM test_transactions")
+	if err != nil {
+		t.Fatalf("Failed to clear transactions: %v", err)
+	}
+	// Next transfer starts a new chain
+	_, err = db.Exec("UPDATE test_transactions_head SET hash = $1", strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatalf("Failed to reset chain head: %v", err)
+	}
+}
+
+func clearTreasuryAudit(t testing.TB, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec("DELETE FROM test_treasury_audit")
+	if err != nil {
+		t.Fatalf("Failed to clear treasury audit: %v", err)
+	}
+}
+
+func getBalance(t testing.TB, db *sql.DB, address string) string {
 	t.Helper()
 	var balance string
-	err := db.QueryRow("SELECT token_balance FROM test_wallets WHERE address = $1", address).Scan(&balance)
+	err := db.QueryRow("SELECT token_balance FROM test_wallets WHERE address = $1 AND asset = 'TOKEN'", strings.ToLower(address)).Scan(&balance)
 	if err != nil {
 		t.Fatalf("Failed to get balance for %s: %v", address, err)
 	}
@@ -64,8 +87,16 @@ func assertBalance(t *testing.T, db *sql.DB, expectedA, addrA string) {
 func doTransfer(t *testing.T, resolver graph.MutationResolver, ctx context.Context, fromAddress, toAddress, amount string) {
 	t.Helper()
 
-	_, err := resolver.Transfer(ctx, fromAddress, toAddress, amount)
+	_, err := resolver.Transfer(ctx, fromAddress, toAddress, amount, nil, nil)
 	if err != nil {
 		t.Errorf("Transfer %s → %s failed: %v", fromAddress, toAddress, err)
 	}
 }
+
+func clearAllowances(t testing.TB, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec("DELETE FROM test_allowances")
+	if err != nil {
+		t.Fatalf("Failed to clear allowances: %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package graph_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/model"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestBalanceDelta(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// A sends 100, receives 30.5 back
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+	doTransfer(t, mutation, ctx, bAddress, aAddress, "30.5")
+
+	from := time.Now().Add(-time.Hour)
+	to := time.Now().Add(time.Hour)
+
+	// Net-negative period for A
+	delta, err := query.BalanceDelta(ctx, aAddress, from, to)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if delta != "-69.500000000000000000" {
+		t.Errorf("Expected A delta -69.500000000000000000, got %s", delta)
+	}
+
+	// Net-positive period for B
+	delta, err = query.BalanceDelta(ctx, bAddress, from, to)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if delta != "+69.500000000000000000" {
+		t.Errorf("Expected B delta +69.500000000000000000, got %s", delta)
+	}
+
+	// Period without transactions
+	delta, err = query.BalanceDelta(ctx, aAddress, from.Add(-time.Hour), from)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if delta != "0.000000000000000000" {
+		t.Errorf("Expected zero delta for empty period, got %s", delta)
+	}
+}
+
+func TestReconcileWallet(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "0")
+	initWallet(t, db, bAddress, "0")
+	initWallet(t, db, cAddress, "1000")
+
+	// Wallet without transactions and zero balance is consistent
+	result, err := query.ReconcileWallet(ctx, aAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !result.Consistent || result.ComputedBalance != "0.000000000000000000" {
+		t.Errorf("Expected consistent zero balance, got %+v", result)
+	}
+
+	// Funds received only through transfers are fully explained by the log
+	doTransfer(t, mutation, ctx, cAddress, aAddress, "100")
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "30.5")
+	result, err = query.ReconcileWallet(ctx, aAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !result.Consistent || result.StoredBalance != "69.500000000000000000" || result.ComputedBalance != "69.500000000000000000" {
+		t.Errorf("Expected consistent balance 69.5, got %+v", result)
+	}
+
+	// Mints and burns are logged against "supply", so they are explained by the log too
+	resolver.MintEnabled = true
+	if _, err := mutation.Mint(ctx, bAddress, "10", nil); err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if _, err := mutation.Burn(ctx, bAddress, "0.5", nil); err != nil {
+		t.Fatalf("Burn failed: %v", err)
+	}
+	result, err = query.ReconcileWallet(ctx, bAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !result.Consistent || result.StoredBalance != "40.000000000000000000" {
+		t.Errorf("Expected consistent balance 40, got %+v", result)
+	}
+
+	// Seeded balance is not in the log
+	result, err = query.ReconcileWallet(ctx, cAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if result.Consistent || result.StoredBalance != "900.000000000000000000" || result.ComputedBalance != "-100.000000000000000000" {
+		t.Errorf("Expected inconsistent balance 900 vs -100, got %+v", result)
+	}
+
+	// Unknown wallet
+	if _, err := query.ReconcileWallet(ctx, "0xd000000000000000000000000000000000000000"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows for unknown wallet, got: %v", err)
+	}
+}
+
+func TestFlowMatrix(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+	query := resolver.Query()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	cAddress := "0xc000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Flows: A -> B = 150 (2 transfers), B -> C = 30, A -> C = 10
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "50")
+	doTransfer(t, mutation, ctx, bAddress, cAddress, "30")
+	doTransfer(t, mutation, ctx, aAddress, cAddress, "10")
+
+	from := time.Now().Add(-time.Hour)
+	to := time.Now().Add(time.Hour)
+	topN := int32(2)
+
+	edges, err := query.FlowMatrix(ctx, from, to, &topN)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Check top edges and their summed volumes
+	expected := []struct {
+		from, to, volume string
+		transfers        int32
+	}{
+		{aAddress, bAddress, "150.000000000000000000", 2},
+		{bAddress, cAddress, "30.000000000000000000", 1},
+	}
+	if len(edges) != len(expected) {
+		t.Fatalf("Expected %d edges, got %d", len(expected), len(edges))
+	}
+	for i, e := range expected {
+		edge := edges[i]
+		if edge.FromAddress != e.from || edge.ToAddress != e.to || edge.Volume != e.volume || edge.Transfers != e.transfers {
+			t.Errorf("Edge %d: expected %s -> %s %s (%d), got %s -> %s %s (%d)",
+				i, e.from, e.to, e.volume, e.transfers,
+				edge.FromAddress, edge.ToAddress, edge.Volume, edge.Transfers)
+		}
+	}
+
+	// Invalid top_n
+	zero := int32(0)
+	if _, err := query.FlowMatrix(ctx, from, to, &zero); err == nil {
+		t.Error("FlowMatrix with top_n 0 did not throw error")
+	}
+
+	// Invalid time window
+	if _, err := query.FlowMatrix(ctx, to, from, nil); err == nil {
+		t.Error("FlowMatrix with reversed time window did not throw error")
+	}
+}
+
+// Insert transaction log row with given age; hashes are placeholders
+func seedTransaction(t *testing.T, db *sql.DB, from, to, amount string, age time.Duration) {
+	t.Helper()
+	hash := fmt.Sprintf("seed-%s-%s-%d", from, amount, age)
+	_, err := db.Exec(`INSERT INTO test_transactions (from_address, to_address, amount, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3::numeric, $4, '', $5)`, strings.ToLower(from), strings.ToLower(to), amount, time.Now().Add(-age), hash)
+	if err != nil {
+		t.Fatalf("Failed to seed transaction: %v", err)
+	}
+}
+
+func TestTransferRate(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	query := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearTransactions(t, db)
+	seedTransaction(t, db, aAddress, bAddress, "1", 10*time.Second)
+	seedTransaction(t, db, aAddress, bAddress, "2", 30*time.Second)
+	seedTransaction(t, db, aAddress, bAddress, "4", 30*time.Minute)
+	seedTransaction(t, db, aAddress, bAddress, "8", 5*time.Hour)
+	seedTransaction(t, db, aAddress, bAddress, "16", 48*time.Hour)
+	// Incoming transfers are not counted
+	seedTransaction(t, db, bAddress, aAddress, "32", 10*time.Second)
+
+	tests := []struct {
+		window    string
+		transfers int32
+		volume    string
+	}{
+		{"minute", 2, "3.000000000000000000"},
+		{"hour", 3, "7.000000000000000000"},
+		{"day", 4, "15.000000000000000000"},
+	}
+	for _, tt := range tests {
+		stats, err := query.TransferRate(ctx, aAddress, tt.window)
+		if err != nil {
+			t.Fatalf("Expected no error for window %s but got: %v", tt.window, err)
+		}
+		if stats.Transfers != tt.transfers || stats.Volume != tt.volume {
+			t.Errorf("Window %s: expected %d transfers / %s, got %d / %s",
+				tt.window, tt.transfers, tt.volume, stats.Transfers, stats.Volume)
+		}
+	}
+
+	// Unknown window
+	_, err := query.TransferRate(ctx, aAddress, "week")
+	if err == nil {
+		t.Fatal("Unknown window did not throw error")
+	}
+}
+
+func TestTransferWithMemo(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "100")
+
+	memo := "invoice 2024-17"
+	if _, err := mutation.TransferWithMemo(ctx, aAddress, bAddress, "10", &memo); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Check if memo was stored with the transaction
+	var stored sql.NullString
+	if err := db.QueryRow("SELECT memo FROM test_transactions WHERE from_address = $1", strings.ToLower(aAddress)).Scan(&stored); err != nil {
+		t.Fatalf("Failed to read memo: %v", err)
+	}
+	if stored.String != memo {
+		t.Errorf("Expected memo %q, got %q", memo, stored.String)
+	}
+
+	// Too long memo and control characters are rejected without moving tokens
+	for _, invalid := range []string{strings.Repeat("x", 257), "line\nbreak"} {
+		if _, err := mutation.TransferWithMemo(ctx, aAddress, bAddress, "10", &invalid); err == nil {
+			t.Errorf("Transfer with memo %q did not throw error", invalid)
+		}
+	}
+	assertBalance(t, db, "90", aAddress)
+}
+
+func TestTransactionsFilters(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+
+	query := resolver.Query()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	cAddress := "0xc000000000000000000000000000000000000000"
+
+	// Clean and seed test data; two rows share a timestamp, so id breaks the tie
+	clearTransactions(t, db)
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	insert := func(from, to, amount string, at time.Time) {
+		t.Helper()
+		_, err := db.Exec(`INSERT INTO test_transactions (from_address, to_address, amount, created_at, prev_hash, hash)
+			VALUES ($1, $2, $3::numeric, $4, '', $5)`, from, to, amount, at, "seed-"+amount)
+		if err != nil {
+			t.Fatalf("Failed to seed transaction: %v", err)
+		}
+	}
+	insert(aAddress, bAddress, "1", base)
+	insert(bAddress, aAddress, "2", base.Add(time.Minute))
+	insert(aAddress, cAddress, "3", base.Add(2*time.Minute))
+	insert(aAddress, bAddress, "4", base.Add(2*time.Minute))
+	insert(cAddress, bAddress, "5", base.Add(3*time.Minute))
+
+	amounts := func(connection *model.TransactionConnection) string {
+		var out []string
+		for _, edge := range connection.Edges {
+			out = append(out, strings.TrimSuffix(edge.Node.Amount, ".000000000000000000"))
+		}
+		return strings.Join(out, ",")
+	}
+	direction := func(d model.TransferDirection) *model.TransferDirection { return &d }
+	at := func(offset time.Duration) *time.Time { ts := base.Add(offset); return &ts }
+	first := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name      string
+		direction *model.TransferDirection
+		from, to  *time.Time
+		expected  string
+	}{
+		{"all by default", nil, nil, nil, "1,2,3,4"},
+		{"sent", direction(model.TransferDirectionSent), nil, nil, "1,3,4"},
+		{"received", direction(model.TransferDirectionReceived), nil, nil, "2"},
+		{"only from", nil, at(time.Minute), nil, "2,3,4"},
+		{"only to", nil, nil, at(2 * time.Minute), "1,2"},
+		{"sent in range", direction(model.TransferDirectionSent), at(time.Minute), at(3 * time.Minute), "3,4"},
+	}
+	for _, tt := range tests {
+		connection, err := query.Transactions(ctx, aAddress, tt.direction, tt.from, tt.to, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: expected no error but got: %v", tt.name, err)
+		}
+		if got := amounts(connection); got != tt.expected {
+			t.Errorf("%s: expected amounts %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+
+	// Pages continue after the cursor, also between rows with the same timestamp
+	page, err := query.Transactions(ctx, aAddress, nil, nil, nil, first(3), nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if amounts(page) != "1,2,3" || !page.PageInfo.HasNextPage || page.PageInfo.EndCursor == nil {
+		t.Fatalf("Unexpected first page: %s, %+v", amounts(page), page.PageInfo)
+	}
+	page, err = query.Transactions(ctx, aAddress, nil, nil, nil, first(3), page.PageInfo.EndCursor)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if amounts(page) != "4" || page.PageInfo.HasNextPage {
+		t.Errorf("Unexpected second page: %s, %+v", amounts(page), page.PageInfo)
+	}
+	if !page.Edges[0].Node.Timestamp.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("Expected timestamp %s, got %s", base.Add(2*time.Minute), page.Edges[0].Node.Timestamp)
+	}
+
+	// Malformed cursor
+	invalid := "not-a-cursor"
+	if _, err := query.Transactions(ctx, aAddress, nil, nil, nil, nil, &invalid); err == nil {
+		t.Error("Invalid cursor did not throw error")
+	}
+}
@@ -0,0 +1,93 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInitialize(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	treasuryAddress := "0x0000000000000000000000000000000000000000"
+	resolver := &graph.Resolver{
+		DB:              db,
+		WalletTable:     "test_wallets",
+		TreasuryAddress: treasuryAddress,
+	}
+
+	// Clean data
+	clearWallets(t, db)
+
+	// First init creates treasury with configured supply
+	if err := resolver.Initialize(ctx, decimal.RequireFromString("5000")); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	assertBalance(t, db, "5000", treasuryAddress)
+
+	// Second init is rejected
+	err := resolver.Initialize(ctx, decimal.RequireFromString("7000"))
+	// Check if init throws error
+	if err == nil {
+		t.Fatal("Second initialization did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "already initialized") {
+		t.Fatalf("Expected 'already initialized' error, got: %v", err)
+	}
+
+	// Check supply was not changed by rejected init
+	assertBalance(t, db, "5000", treasuryAddress)
+}
+
+func TestInitialize_LogsSupply(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	treasuryAddress := "0x0000000000000000000000000000000000000000"
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+		TreasuryAddress:  treasuryAddress,
+	}
+
+	// Clean data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+
+	if err := resolver.Initialize(ctx, decimal.RequireFromString("5000")); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Initial supply is the first link of the chain, coming from "supply"
+	var from, amount string
+	if err := db.QueryRow("SELECT from_address, amount FROM test_transactions WHERE to_address = $1", treasuryAddress).Scan(&from, &amount); err != nil {
+		t.Fatalf("Failed to read initial supply transaction: %v", err)
+	}
+	if from != "supply" || amount != "5000.000000000000000000" {
+		t.Errorf("Expected 5000 from supply, got %s from %s", amount, from)
+	}
+
+	// Treasury balance is explained by the log
+	result, err := resolver.Query().ReconcileWallet(ctx, treasuryAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !result.Consistent {
+		t.Errorf("Expected consistent treasury balance, got %+v", result)
+	}
+	chain, err := resolver.Query().VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !chain.Valid || chain.Checked != 1 {
+		t.Errorf("Expected valid chain of 1 transaction, got %+v", chain)
+	}
+}
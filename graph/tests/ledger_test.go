@@ -0,0 +1,193 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestLedgerExportImport(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                  db,
+		WalletTable:         "test_wallets",
+		TransactionTable:    "test_transactions",
+		MintEnabled:         true,
+		LedgerExportEnabled: true,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Clean and seed test data; minted, so every balance is explained by the log
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	if _, err := mutation.Mint(ctx, aAddress, "1000", nil); err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+	doTransfer(t, mutation, ctx, bAddress, cAddress, "25.5")
+	if _, err := mutation.LinkWallet(ctx, bAddress, "user-1"); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+
+	ledger, err := resolver.Query().ExportLedger(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// Import into a fresh table set
+	fresh, err := testutils.NewSandbox(testutils.ConnStr)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer fresh.Close()
+
+	restored := &graph.Resolver{
+		DB:                  fresh.DB,
+		WalletTable:         "test_wallets",
+		TransactionTable:    "test_transactions",
+		LedgerImportEnabled: true,
+		LedgerExportEnabled: true,
+	}
+
+	summary, err := restored.Mutation().ImportLedger(ctx, ledger)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if summary.Wallets != 3 || summary.Transactions != 3 || summary.Supply != "1000.000000000000000000" {
+		t.Errorf("Unexpected import summary: %+v", summary)
+	}
+
+	// Restored state exports to the same ledger
+	restoredLedger, err := restored.Query().ExportLedger(ctx)
+	if err != nil {
+		t.Fatalf("Export of restored state failed: %v", err)
+	}
+	if restoredLedger != ledger {
+		t.Errorf("Restored ledger differs\nexpected:\n%s\ngot:\n%s", ledger, restoredLedger)
+	}
+
+	// Restored log is still a valid chain and can be extended
+	if _, err := restored.Mutation().Transfer(ctx, cAddress, aAddress, "1", nil, nil); err != nil {
+		t.Fatalf("Transfer after import failed: %v", err)
+	}
+	verification, err := restored.Query().VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("Chain verification failed: %v", err)
+	}
+	if !verification.Valid || verification.Checked != 4 {
+		t.Errorf("Expected valid chain of 4 transactions, got %+v", verification)
+	}
+
+	// Import into non-empty tables is rejected
+	_, err = restored.Mutation().ImportLedger(ctx, ledger)
+	if err == nil || !strings.Contains(err.Error(), "requires empty tables") {
+		t.Fatalf("Expected 'requires empty tables' error, got: %v", err)
+	}
+}
+
+func TestLedgerImport_Invalid(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                  db,
+		WalletTable:         "test_wallets",
+		TransactionTable:    "test_transactions",
+		LedgerImportEnabled: true,
+		LedgerExportEnabled: true,
+		MintEnabled:         true,
+	}
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	ledger, err := resolver.Query().ExportLedger(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	clearWallets(t, db)
+
+	// Supply not matching balances
+	tampered := strings.Replace(ledger, `"balance":"1000.000000000000000000"`, `"balance":"2000.000000000000000000"`, 1)
+	_, err = resolver.Mutation().ImportLedger(ctx, tampered)
+	if err == nil || !strings.Contains(err.Error(), "supply does not match") {
+		t.Fatalf("Expected 'supply does not match' error, got: %v", err)
+	}
+
+	// Nothing written
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_wallets").Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no wallets after rejected import, got %d", count)
+	}
+
+	// Two spellings of the same wallet collide once lowercased
+	duplicate := strings.Replace(ledger, `"wallets":1`, `"wallets":2`, 1) +
+		`{"type":"wallet","address":"` + aAddress + `","balance":"0"}` + "\n"
+	_, err = resolver.Mutation().ImportLedger(ctx, duplicate)
+	if err == nil || !strings.Contains(err.Error(), "duplicate wallet") {
+		t.Fatalf("Expected 'duplicate wallet' error, got: %v", err)
+	}
+
+	// Checksum-cased wallets are stored lowercase
+	checksummed := strings.Replace(ledger, strings.ToLower(aAddress), aAddress, 1)
+	if _, err := resolver.Mutation().ImportLedger(ctx, checksummed); err != nil {
+		t.Fatalf("Import of checksum-cased ledger failed: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_wallets WHERE address = $1", strings.ToLower(aAddress)).Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected imported wallet under lowercase address, got %d rows", count)
+	}
+	clearWallets(t, db)
+
+	// Balances not explained by the log, though their sum still matches the supply
+	clearTransactions(t, db)
+	if _, err := resolver.Mutation().Mint(ctx, aAddress, "1000", nil); err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	doTransfer(t, resolver.Mutation(), ctx, aAddress, bAddress, "100")
+	logged, err := resolver.Query().ExportLedger(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	clearWallets(t, db)
+	clearTransactions(t, db)
+	tampered = strings.Replace(logged, `"balance":"900.000000000000000000"`, `"balance":"800.000000000000000000"`, 1)
+	tampered = strings.Replace(tampered, `"balance":"100.000000000000000000"`, `"balance":"200.000000000000000000"`, 1)
+	_, err = resolver.Mutation().ImportLedger(ctx, tampered)
+	if err == nil || !strings.Contains(err.Error(), "does not match its transactions") {
+		t.Fatalf("Expected 'does not match its transactions' error, got: %v", err)
+	}
+
+	// Export disabled
+	resolver.LedgerExportEnabled = false
+	if _, err := resolver.Query().ExportLedger(ctx); err == nil || !strings.Contains(err.Error(), "ledger export is disabled") {
+		t.Fatalf("Expected 'ledger export is disabled' error, got: %v", err)
+	}
+
+	// Import disabled
+	resolver.LedgerImportEnabled = false
+	_, err = resolver.Mutation().ImportLedger(ctx, ledger)
+	if err == nil || !strings.Contains(err.Error(), "ledger import is disabled") {
+		t.Fatalf("Expected 'ledger import is disabled' error, got: %v", err)
+	}
+}
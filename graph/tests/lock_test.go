@@ -0,0 +1,437 @@
+package graph_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRowLockConcurrentTransfers(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:           db,
+		WalletTable:  "test_wallets",
+		LockStrategy: graph.LockRow,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+	initWallet(t, db, bAddress, "1000")
+
+	// 25 transfers A -> B (amount 5) and 25 transfers B -> A (amount 10), all at once
+	const transferCount = 50
+	var wg sync.WaitGroup
+	wg.Add(transferCount)
+	start := make(chan struct{})
+
+	for i := 0; i < transferCount; i++ {
+		fromAddress, toAddress, amount := aAddress, bAddress, "5"
+		if i%2 == 1 {
+			fromAddress, toAddress, amount = bAddress, aAddress, "10"
+		}
+
+		go func(from, to, amount string) {
+			defer wg.Done()
+			<-start
+
+			doTransfer(t, mutation, ctx, from, to, amount)
+		}(fromAddress, toAddress, amount)
+	}
+
+	close(start)
+	wg.Wait()
+
+	// Same result as with advisory locks: no lost updates, no deadlock
+	assertBalance(t, db, "1125", aAddress)
+	assertBalance(t, db, "875", bAddress)
+}
+
+func TestConcurrentTransfersToNewRecipient(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Row locks and optimistic mode have no row to lock yet, so both transfers insert C
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow, graph.LockOptimistic} {
+		t.Run(string(strategy), func(t *testing.T) {
+			ctx := context.Background()
+			resolver := &graph.Resolver{
+				DB:                 db,
+				WalletTable:        "test_wallets",
+				LockStrategy:       strategy,
+				OptimisticAttempts: 10,
+			}
+
+			mutation := resolver.Mutation()
+
+			// Clean and seed test data, recipient C does not exist yet
+			clearWallets(t, db)
+			initWallet(t, db, aAddress, "100")
+			initWallet(t, db, bAddress, "100")
+
+			// A -> C and B -> C at the same time; both create C
+			var wg sync.WaitGroup
+			wg.Add(2)
+			start := make(chan struct{})
+
+			for _, fromAddress := range []string{aAddress, bAddress} {
+				go func(from string) {
+					defer wg.Done()
+					<-start
+
+					doTransfer(t, mutation, ctx, from, cAddress, "10")
+				}(fromAddress)
+			}
+
+			close(start)
+			wg.Wait()
+
+			// Both transfers credited the same new wallet
+			assertBalance(t, db, "20", cAddress)
+			assertBalance(t, db, "90", aAddress)
+			assertBalance(t, db, "90", bAddress)
+		})
+	}
+}
+
+func TestConcurrentTransfersFromNewSender(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	cAddress := "0xc000000000000000000000000000000000000000"
+
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow, graph.LockOptimistic} {
+		t.Run(string(strategy), func(t *testing.T) {
+			ctx := context.Background()
+			resolver := &graph.Resolver{
+				DB:                   db,
+				WalletTable:          "test_wallets",
+				LockStrategy:         strategy,
+				OptimisticAttempts:   10,
+				AutoCreateSender:     true,
+				DefaultSenderBalance: decimal.NewFromInt(100),
+			}
+
+			mutation := resolver.Mutation()
+
+			// Clean test data, sender A does not exist yet
+			clearWallets(t, db)
+			initWallet(t, db, bAddress, "0")
+			initWallet(t, db, cAddress, "0")
+
+			// A -> B and A -> C at the same time; both create A
+			var wg sync.WaitGroup
+			wg.Add(2)
+			start := make(chan struct{})
+
+			for _, toAddress := range []string{bAddress, cAddress} {
+				go func(to string) {
+					defer wg.Done()
+					<-start
+
+					doTransfer(t, mutation, ctx, aAddress, to, "10")
+				}(toAddress)
+			}
+
+			close(start)
+			wg.Wait()
+
+			// A was created once with the default balance and debited twice
+			assertBalance(t, db, "80", aAddress)
+			assertBalance(t, db, "10", bAddress)
+			assertBalance(t, db, "10", cAddress)
+		})
+	}
+}
+
+func BenchmarkTransferLockStrategy(b *testing.B) {
+	db := testutils.SetupDB(b)
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow} {
+		b.Run(string(strategy), func(b *testing.B) {
+			resolver := &graph.Resolver{
+				DB:           db,
+				WalletTable:  "test_wallets",
+				LockStrategy: strategy,
+			}
+			mutation := resolver.Mutation()
+			ctx := context.Background()
+
+			// Clean and seed test data
+			clearWallets(b, db)
+			initWallet(b, db, aAddress, "1000000")
+			initWallet(b, db, bAddress, "1000000")
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				forward := true
+				for pb.Next() {
+					from, to := aAddress, bAddress
+					if !forward {
+						from, to = bAddress, aAddress
+					}
+					forward = !forward
+
+					if _, err := mutation.Transfer(ctx, from, to, "0.000000000000000001", nil, nil); err != nil {
+						b.Errorf("Transfer %s → %s failed: %v", from, to, err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestOptimisticConcurrentTransfers(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                 db,
+		WalletTable:        "test_wallets",
+		LockStrategy:       graph.LockOptimistic,
+		OptimisticAttempts: 100,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+	initWallet(t, db, bAddress, "1000")
+
+	// 25 transfers A -> B (amount 5) and 25 transfers B -> A (amount 10), all at once
+	const transferCount = 50
+	var wg sync.WaitGroup
+	wg.Add(transferCount)
+	start := make(chan struct{})
+
+	var mu sync.Mutex
+	succeeded := map[string]int{}
+
+	for i := 0; i < transferCount; i++ {
+		fromAddress, toAddress, amount := aAddress, bAddress, "5"
+		if i%2 == 1 {
+			fromAddress, toAddress, amount = bAddress, aAddress, "10"
+		}
+
+		go func(from, to, amount string) {
+			defer wg.Done()
+			<-start
+
+			// Only running out of attempts is acceptable
+			_, err := mutation.Transfer(ctx, from, to, amount, nil, nil)
+			if err != nil {
+				if !errors.Is(err, graph.ErrWriteConflict) {
+					t.Errorf("Unexpected transfer error: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			succeeded[from]++
+			mu.Unlock()
+		}(fromAddress, toAddress, amount)
+	}
+
+	close(start)
+	wg.Wait()
+
+	// No lost updates: balances match exactly the transfers that succeeded
+	fromA, fromB := succeeded[aAddress], succeeded[bAddress]
+	expectedA := decimal.NewFromInt(int64(1000 - 5*fromA + 10*fromB))
+	expectedB := decimal.NewFromInt(int64(1000 + 5*fromA - 10*fromB))
+	assertBalance(t, db, expectedA.String(), aAddress)
+	assertBalance(t, db, expectedB.String(), bAddress)
+
+	// Every successful transfer changed both wallets once
+	var version int64
+	if err := db.QueryRow("SELECT version FROM test_wallets WHERE address = $1", aAddress).Scan(&version); err != nil {
+		t.Fatalf("Failed to read version: %v", err)
+	}
+	if version != int64(fromA+fromB) {
+		t.Errorf("Expected version %d, got %d", fromA+fromB, version)
+	}
+}
+
+func TestOptimisticWriteConflict(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                 db,
+		WalletTable:        "test_wallets",
+		LockStrategy:       graph.LockOptimistic,
+		OptimisticAttempts: 1,
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+	initWallet(t, db, bAddress, "1000")
+
+	// Concurrent update committed between read and write
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE test_wallets SET token_balance = token_balance + 1, version = version + 1 WHERE address = $1", aAddress); err != nil {
+		t.Fatalf("Failed to update wallet: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := resolver.Mutation().Transfer(ctx, aAddress, bAddress, "5", nil, nil)
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	tx.Commit()
+
+	// Single attempt: the stale version is reported, not silently overwritten
+	if err := <-done; !errors.Is(err, graph.ErrWriteConflict) {
+		t.Fatalf("Expected ErrWriteConflict, got: %v", err)
+	}
+	assertBalance(t, db, "1001", aAddress)
+	assertBalance(t, db, "1000", bAddress)
+
+	// With retries the transfer reads the new version and succeeds
+	resolver.OptimisticAttempts = 3
+	doTransfer(t, resolver.Mutation(), ctx, aAddress, bAddress, "5")
+	assertBalance(t, db, "996", aAddress)
+}
+
+func TestTransferRetriedAfterDeadlock(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:              db,
+		WalletTable:     "test_wallets",
+		LockStrategy:    graph.LockRow,
+		TransferRetries: 3,
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+	initWallet(t, db, bAddress, "1000")
+
+	// Concurrent transaction locking the wallets in the opposite order: B first
+	// Its high deadlock_timeout makes Postgres abort the transfer, not this transaction
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SET LOCAL deadlock_timeout = '30s'"); err != nil {
+		t.Fatalf("Failed to set deadlock_timeout: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE test_wallets SET token_balance = token_balance + 1 WHERE address = $1", bAddress); err != nil {
+		t.Fatalf("Failed to update wallet: %v", err)
+	}
+
+	// Transfer locks A, then waits for B
+	done := make(chan error, 1)
+	go func() {
+		_, err := resolver.Mutation().Transfer(ctx, aAddress, bAddress, "5", nil, nil)
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// Waiting for A closes the cycle; it returns once the transfer is aborted
+	if _, err := tx.Exec("UPDATE test_wallets SET token_balance = token_balance + 1 WHERE address = $1", aAddress); err != nil {
+		t.Fatalf("Failed to update wallet: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// Transfer is run again after the deadlock and applied on top of the concurrent update
+	if err := <-done; err != nil {
+		t.Fatalf("Expected transfer to succeed after retry, got: %v", err)
+	}
+	assertBalance(t, db, "996", aAddress)
+	assertBalance(t, db, "1006", bAddress)
+}
+
+func TestSerializableConcurrentTransfers(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Locks and SERIALIZABLE together are redundant but must stay correct:
+	// transfers aborted with a serialization failure are retried
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow} {
+		resolver := &graph.Resolver{
+			DB:              db,
+			WalletTable:     "test_wallets",
+			LockStrategy:    strategy,
+			IsolationLevel:  sql.LevelSerializable,
+			TransferRetries: 20,
+		}
+		mutation := resolver.Mutation()
+
+		// Clean and seed test data
+		clearWallets(t, db)
+		initWallet(t, db, aAddress, "1000")
+		initWallet(t, db, bAddress, "1000")
+
+		// 5 transfers A -> B (amount 5) and 5 transfers B -> A (amount 10), all at once
+		const transferCount = 10
+		var wg sync.WaitGroup
+		wg.Add(transferCount)
+		start := make(chan struct{})
+
+		for i := 0; i < transferCount; i++ {
+			fromAddress, toAddress, amount := aAddress, bAddress, "5"
+			if i%2 == 1 {
+				fromAddress, toAddress, amount = bAddress, aAddress, "10"
+			}
+
+			go func(from, to, amount string) {
+				defer wg.Done()
+				<-start
+
+				doTransfer(t, mutation, ctx, from, to, amount)
+			}(fromAddress, toAddress, amount)
+		}
+
+		close(start)
+		wg.Wait()
+
+		assertBalance(t, db, "1025", aAddress)
+		assertBalance(t, db, "975", bAddress)
+	}
+}
@@ -26,12 +26,12 @@ func TestMain(m *testing.M) {
 		os.Getenv("DB_PORT"),
 	)
 
-	// Open DB connection
-	var err error
-	testDB, err = sql.Open("postgres", connStr)
+	// Each run works in its own schema, so parallel runs and production tables never interfere
+	sandbox, err := testutils.NewSandbox(connStr)
 	if err != nil {
-		log.Fatalf("Failed to open DB: %v", err)
+		log.Fatalf("Failed to create sandbox: %v", err)
 	}
+	testDB = sandbox.DB
 
 	// Check if DB is reachable
 	if err := testDB.Ping(); err != nil {
@@ -40,14 +40,14 @@ func TestMain(m *testing.M) {
 
 	// Share testDB with other tests by testultis
 	testutils.DB = testDB
+	testutils.ConnStr = connStr
 
 	code := m.Run()
 
-	// Clean and reset database state
-	if err := testutils.ResetDatabaseState(testDB); err != nil {
-		log.Fatalf("Failed to reset DB: %v", err)
+	// Drop sandbox with all test data
+	if err := sandbox.Close(); err != nil {
+		log.Fatalf("Failed to drop sandbox: %v", err)
 	}
 
-	_ = testDB.Close()
 	os.Exit(code)
 }
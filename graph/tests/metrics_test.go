@@ -0,0 +1,193 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestValidationFailuresCounter(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	// Unauthenticated request: counted as anonymous, not by its client IP
+	ctx := graph.WithClientIP(context.Background(), "203.0.113.7")
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// One rejected transfer for every validation failure reason
+	cases := []struct {
+		reason    string
+		toAddress string
+		amount    string
+	}{
+		{"invalid_address", "0xG000000000000000000000000000000000000000", "1"},
+		{"same_address", aAddress, "1"},
+		{"invalid_amount", bAddress, "abc123"},
+		{"non_positive_amount", bAddress, "-12"},
+		{"too_many_decimals", bAddress, "1.1234567890123456789"},
+		{"too_many_digits", bAddress, "12345678901234567890123456789.0"},
+	}
+
+	for _, c := range cases {
+		counter := graph.ValidationFailures.WithLabelValues(c.reason, "anonymous")
+		before := testutil.ToFloat64(counter)
+
+		_, err := mutation.Transfer(ctx, aAddress, c.toAddress, c.amount, nil, nil)
+		// Check if transfer throws error
+		if err == nil {
+			t.Fatalf("Transfer expected to fail with %s did not throw error", c.reason)
+		}
+
+		// Check if counter for this reason was incremented
+		if after := testutil.ToFloat64(counter); after != before+1 {
+			t.Errorf("Expected %s counter to be %v, got %v", c.reason, before+1, after)
+		}
+	}
+}
+
+func TestValidationFailuresLog(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	// Capture log output
+	var logs bytes.Buffer
+
+	// API key identity wins over the client IP
+	ctx := graph.WithActor(graph.WithClientIP(context.Background(), "203.0.113.7"), "api-key-2")
+	resolver := &graph.Resolver{
+		DB:                    db,
+		WalletTable:           "test_wallets",
+		Logger:                slog.New(slog.NewJSONHandler(&logs, nil)),
+		LogValidationFailures: true,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "abc123", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer with invalid amount did not throw error")
+	}
+
+	// Check if rejection was logged with its reason and source
+	if !strings.Contains(logs.String(), `"reason":"invalid_amount","source":"api-key-2"`) {
+		t.Errorf("Expected log line with reason invalid_amount from api-key-2, got: %s", logs.String())
+	}
+}
+
+func TestTransferMetrics(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	successes := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("success"))
+	failures := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("failure"))
+	insufficient := testutil.ToFloat64(graph.TransferFailures.WithLabelValues("insufficient_balance"))
+	invalidAddress := testutil.ToFloat64(graph.TransferFailures.WithLabelValues("invalid_address"))
+	amountSum := testutil.ToFloat64(graph.TransferAmountSum)
+
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "2.5")
+	if _, err := mutation.Transfer(ctx, aAddress, bAddress, "100", nil, nil); err == nil {
+		t.Fatal("Transfer with insufficient balance did not throw error")
+	}
+	if _, err := mutation.Transfer(ctx, aAddress, "0x123", "1", nil, nil); err == nil {
+		t.Fatal("Transfer with invalid address did not throw error")
+	}
+
+	// Check counters
+	if got := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("success")) - successes; got != 1 {
+		t.Errorf("Expected 1 successful transfer, got %v", got)
+	}
+	if got := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("failure")) - failures; got != 2 {
+		t.Errorf("Expected 2 failed transfers, got %v", got)
+	}
+	if got := testutil.ToFloat64(graph.TransferFailures.WithLabelValues("insufficient_balance")) - insufficient; got != 1 {
+		t.Errorf("Expected 1 insufficient_balance failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(graph.TransferFailures.WithLabelValues("invalid_address")) - invalidAddress; got != 1 {
+		t.Errorf("Expected 1 invalid_address failure, got %v", got)
+	}
+	if got := testutil.ToFloat64(graph.TransferAmountSum) - amountSum; got != 2.5 {
+		t.Errorf("Expected transfer amount sum to grow by 2.5, got %v", got)
+	}
+}
+
+func TestRateLimitedTransferSource(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	// Capture audit log output
+	var logs bytes.Buffer
+
+	clientIP := "198.51.100.23"
+	ctx := graph.WithClientIP(context.Background(), clientIP)
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		AuditLogger:       slog.New(slog.NewJSONHandler(&logs, nil)),
+		TransferRateLimit: 1,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	rateLimited := graph.TransferFailures.WithLabelValues("rate_limited")
+	before := testutil.ToFloat64(rateLimited)
+
+	// Second transfer within the minute exceeds the limit
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+	if _, err := mutation.Transfer(ctx, aAddress, bAddress, "1", nil, nil); !errors.Is(err, graph.ErrRateLimitExceeded) {
+		t.Fatalf("Expected ErrRateLimitExceeded, got: %v", err)
+	}
+
+	// Check if rejection is counted, and attributed to the client in the audit log only
+	if after := testutil.ToFloat64(rateLimited); after != before+1 {
+		t.Errorf("Expected rate_limited counter to be %v, got %v", before+1, after)
+	}
+	if !strings.Contains(logs.String(), `"reason":"rate_limited","source":"198.51.100.23"`) {
+		t.Errorf("Expected audit line with reason rate_limited from %s, got: %s", clientIP, logs.String())
+	}
+}
@@ -0,0 +1,251 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestMint(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+		MintEnabled: true,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Mint into existing wallet
+	balance, err := mutation.Mint(ctx, aAddress, "5.5", nil)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if balance != "15.500000000000000000" {
+		t.Errorf("Expected balance 15.500000000000000000, got %s", balance)
+	}
+
+	// Mint creates missing wallet
+	balance, err = mutation.Mint(ctx, bAddress, "0.000000000000000001", nil)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if balance != "0.000000000000000001" {
+		t.Errorf("Expected balance 0.000000000000000001, got %s", balance)
+	}
+
+	assertBalance(t, db, "15.5", aAddress)
+	assertBalance(t, db, "0.000000000000000001", bAddress)
+}
+
+func TestMint_ConcurrentLockStrategies(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow, graph.LockOptimistic} {
+		t.Run(string(strategy), func(t *testing.T) {
+			resolver := &graph.Resolver{
+				DB:                 db,
+				WalletTable:        "test_wallets",
+				MintEnabled:        true,
+				LockStrategy:       strategy,
+				OptimisticAttempts: 50,
+			}
+			mutation := resolver.Mutation()
+
+			// Clean and seed test data
+			clearWallets(t, db)
+			initWallet(t, db, aAddress, "0")
+
+			// Concurrent mints to one wallet are all applied, whatever the lock strategy
+			const mintCount = 10
+			var wg sync.WaitGroup
+			for i := 0; i < mintCount; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := mutation.Mint(ctx, aAddress, "1.5", nil); err != nil {
+						t.Errorf("Mint failed: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			assertBalance(t, db, "15", aAddress)
+		})
+	}
+}
+
+func TestMint_Rejected(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+		MintEnabled: true,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "9999999999")
+
+	// Balance would not fit NUMERIC(28,18)
+	_, err := mutation.Mint(ctx, aAddress, "1", nil)
+	// Check if mint throws error
+	if err == nil {
+		t.Fatal("Overflowing mint did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "balance would exceed maximum") {
+		t.Fatalf("Expected 'balance would exceed maximum' error, got: %v", err)
+	}
+
+	// Invalid amount and address
+	if _, err := mutation.Mint(ctx, aAddress, "0", nil); err == nil {
+		t.Error("Mint of zero did not throw error")
+	}
+	if _, err := mutation.Mint(ctx, "0x123", "1", nil); err == nil {
+		t.Error("Mint to invalid address did not throw error")
+	}
+
+	// Minting disabled
+	resolver.MintEnabled = false
+	_, err = mutation.Mint(ctx, aAddress, "0.5", nil)
+	if err == nil || !strings.Contains(err.Error(), "minting is disabled") {
+		t.Fatalf("Expected 'minting is disabled' error, got: %v", err)
+	}
+
+	assertBalance(t, db, "9999999999", aAddress)
+}
+
+func TestBurn(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Burn part of the balance
+	remaining, err := mutation.Burn(ctx, aAddress, "2.25", nil)
+	if err != nil {
+		t.Fatalf("Burn failed: %v", err)
+	}
+	if remaining != "7.750000000000000000" {
+		t.Errorf("Expected remaining balance 7.750000000000000000, got %s", remaining)
+	}
+
+	// Burn more than the balance
+	_, err = mutation.Burn(ctx, aAddress, "7.750000000000000001", nil)
+	// Check if burn throws error
+	if err == nil {
+		t.Fatal("Burn above balance did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "insufficient balance") {
+		t.Fatalf("Expected 'insufficient balance' error, got: %v", err)
+	}
+
+	// Burn the whole balance
+	remaining, err = mutation.Burn(ctx, aAddress, "7.75", nil)
+	if err != nil {
+		t.Fatalf("Burn failed: %v", err)
+	}
+	if remaining != "0.000000000000000000" {
+		t.Errorf("Expected remaining balance 0.000000000000000000, got %s", remaining)
+	}
+
+	// Missing wallet
+	_, err = mutation.Burn(ctx, bAddress, "1", nil)
+	if err == nil || !strings.Contains(err.Error(), "wallet does not exist") {
+		t.Fatalf("Expected 'wallet does not exist' error, got: %v", err)
+	}
+
+	assertBalance(t, db, "0", aAddress)
+}
+
+func TestBurn_ConcurrentLockStrategies(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow, graph.LockOptimistic} {
+		t.Run(string(strategy), func(t *testing.T) {
+			resolver := &graph.Resolver{
+				DB:                 db,
+				WalletTable:        "test_wallets",
+				LockStrategy:       strategy,
+				OptimisticAttempts: 50,
+			}
+			mutation := resolver.Mutation()
+
+			// Clean and seed test data
+			clearWallets(t, db)
+			initWallet(t, db, aAddress, "10")
+
+			// Twice as many burns as the balance covers: the rest fail cleanly instead of overdrawing
+			const burnCount = 20
+			var (
+				wg           sync.WaitGroup
+				mu           sync.Mutex
+				succeeded    int
+				insufficient int
+			)
+			for i := 0; i < burnCount; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := mutation.Burn(ctx, aAddress, "1", nil)
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						succeeded++
+					case errors.Is(err, graph.ErrInsufficientBalance):
+						insufficient++
+					default:
+						t.Errorf("Expected ErrInsufficientBalance, got: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if succeeded != 10 || insufficient != 10 {
+				t.Errorf("Expected 10 burns to succeed and 10 to fail, got %d and %d", succeeded, insufficient)
+			}
+			assertBalance(t, db, "0", aAddress)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestLinkWallet(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+	qr := resolver.Query()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+	initWallet(t, db, bAddress, "20")
+	initWallet(t, db, cAddress, "30")
+
+	// Link two wallets to the same owner
+	wallet, err := mutation.LinkWallet(ctx, bAddress, "user-1")
+	if err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	if wallet.OwnerID == nil || *wallet.OwnerID != "user-1" {
+		t.Errorf("Expected owner user-1, got %v", wallet.OwnerID)
+	}
+	if _, err := mutation.LinkWallet(ctx, aAddress, "user-1"); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+
+	// Linking to the same owner again is a no-op
+	if _, err := mutation.LinkWallet(ctx, aAddress, "user-1"); err != nil {
+		t.Fatalf("Repeated link failed: %v", err)
+	}
+
+	// Query by owner
+	wallets, err := qr.WalletsByOwner(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("walletsByOwner failed: %v", err)
+	}
+	if len(wallets) != 2 || wallets[0].Address != strings.ToLower(aAddress) || wallets[1].Address != strings.ToLower(bAddress) {
+		t.Fatalf("Expected wallets %s and %s, got %+v", aAddress, bAddress, wallets)
+	}
+
+	// Unknown owner has no wallets
+	wallets, err = qr.WalletsByOwner(ctx, "user-2")
+	if err != nil {
+		t.Fatalf("walletsByOwner failed: %v", err)
+	}
+	if len(wallets) != 0 {
+		t.Errorf("Expected no wallets, got %d", len(wallets))
+	}
+}
+
+func TestLinkWallet_ConflictingOwner(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	if _, err := mutation.LinkWallet(ctx, aAddress, "user-1"); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+
+	// Re-link to another owner
+	_, err := mutation.LinkWallet(ctx, aAddress, "user-2")
+	// Check if link throws error
+	if err == nil {
+		t.Fatal("Conflicting link did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "wallet already linked to another owner") {
+		t.Fatalf("Expected 'wallet already linked to another owner' error, got: %v", err)
+	}
+
+	// Re-link is accepted when allowed
+	resolver.AllowOwnerRelink = true
+	wallet, err := mutation.LinkWallet(ctx, aAddress, "user-2")
+	if err != nil {
+		t.Fatalf("Allowed re-link failed: %v", err)
+	}
+	if wallet.OwnerID == nil || *wallet.OwnerID != "user-2" {
+		t.Errorf("Expected owner user-2, got %v", wallet.OwnerID)
+	}
+}
+
+func TestLinkWallet_InvalidInput(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	// Clean and seed test data
+	clearWallets(t, db)
+
+	// Invalid address
+	_, err := mutation.LinkWallet(ctx, "0x123", "user-1")
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Fatalf("Expected invalid address error, got: %v", err)
+	}
+
+	// Empty owner ID
+	_, err = mutation.LinkWallet(ctx, "0xA000000000000000000000000000000000000000", " ")
+	if err == nil || !strings.Contains(err.Error(), "owner ID must be") {
+		t.Fatalf("Expected invalid owner ID error, got: %v", err)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"testing"
 
 	"token_transfer/graph"
+	"token_transfer/graph/model"
 	"token_transfer/graph/tests/testutils"
 )
 
@@ -26,7 +28,7 @@ func TestWalletResolver(t *testing.T) {
 	clearWallets(t, db)
 	initWallet(t, db, aAddress, aBalance)
 
-	wallet, err := qr.Wallet(ctx, aAddress)
+	wallet, err := qr.Wallet(ctx, aAddress, nil)
 	if err != nil {
 		t.Fatalf("Expected no error but got: %v", err)
 	}
@@ -35,13 +37,58 @@ func TestWalletResolver(t *testing.T) {
 		t.Fatal("Expected wallet, got nil")
 	}
 
-	if wallet.Address != aAddress {
+	if wallet.Address != strings.ToLower(aAddress) {
 		t.Errorf("Expected address %s, got %s", aAddress, wallet.Address)
 	}
 
 	assertBalance(t, db, wallet.Balance, aAddress)
 }
 
+func TestBalance(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	qr := resolver.Query()
+
+	// Clean and seed test data
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000.000000000000000000")
+
+	// Trailing zeros trimmed, units as integer 10^-18 base units
+	balance, err := qr.Balance(ctx, aAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if balance.Address != strings.ToLower(aAddress) {
+		t.Errorf("Expected address %s, got %s", aAddress, balance.Address)
+	}
+	if balance.Balance != "1000" {
+		t.Errorf("Expected balance 1000, got %s", balance.Balance)
+	}
+	if balance.Units != "1000000000000000000000" {
+		t.Errorf("Expected units 1000000000000000000000, got %s", balance.Units)
+	}
+
+	// Missing wallet fails like the wallet query
+	_, err = qr.Balance(ctx, bAddress)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got: %v", err)
+	} else if err.Error() != "wallet not found: "+strings.ToLower(bAddress) {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	// Invalid address fails before querying
+	if _, err := qr.Balance(ctx, "0x123"); !errors.Is(err, graph.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got: %v", err)
+	}
+}
+
 func TestWalletResolver_NoWallet(t *testing.T) {
 	db := testutils.SetupDB(t)
 	ctx := context.Background()
@@ -56,7 +103,7 @@ func TestWalletResolver_NoWallet(t *testing.T) {
 	aAddress := "0xA000000000000000000000000000000000000000"
 	clearWallets(t, db)
 
-	_, err := qr.Wallet(ctx, aAddress)
+	_, err := qr.Wallet(ctx, aAddress, nil)
 	if err == nil {
 		t.Fatal("Query about nonexistent wallet did not throw error")
 	}
@@ -65,5 +112,281 @@ func TestWalletResolver_NoWallet(t *testing.T) {
 	if !errors.Is(err, sql.ErrNoRows) {
 		t.Fatalf("Expected 'no rows' error, got: %v", err)
 	}
+	// Clients get a readable message
+	if err.Error() != "wallet not found: "+strings.ToLower(aAddress) {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+}
+
+func TestWalletExists(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	qr := resolver.Query()
+
+	// Clean and seed test data
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "0")
+
+	// Empty wallet exists, mixed-case address is normalized
+	exists, err := qr.WalletExists(ctx, "0xA000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !exists {
+		t.Error("Expected wallet to exist")
+	}
+
+	exists, err = qr.WalletExists(ctx, bAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if exists {
+		t.Error("Expected wallet not to exist")
+	}
+
+	// Invalid address fails before querying
+	if _, err := qr.WalletExists(ctx, "0x123"); !errors.Is(err, graph.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got: %v", err)
+	}
+}
+
+func TestWalletBalanceFormatted(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:               db,
+		WalletTable:      "test_wallets",
+		DecimalSeparator: ",",
+		GroupSeparator:   ".",
+	}
+
+	qr := resolver.Query()
+	wr := resolver.Wallet()
+
+	// Clean and seed test data
+	aAddress := "0xA000000000000000000000000000000000000000"
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1234567.89")
+
+	wallet, err := qr.Wallet(ctx, aAddress, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	str := func(s string) *string { return &s }
+
+	cases := []struct {
+		name             string
+		decimalSeparator *string
+		groupSeparator   *string
+		expected         string
+	}{
+		{"resolver default", nil, nil, "1.234.567,89"},
+		{"en", str("."), str(","), "1,234,567.89"},
+		{"fr", str(","), str(" "), "1 234 567,89"},
+		{"no grouping", str("."), str(""), "1234567.89"},
+	}
+
+	for _, c := range cases {
+		formatted, err := wr.BalanceFormatted(ctx, wallet, c.decimalSeparator, c.groupSeparator)
+		if err != nil {
+			t.Fatalf("%s: expected no error but got: %v", c.name, err)
+		}
+		if formatted != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, formatted)
+		}
+	}
+
+	// Canonical balance is not changed by formatting
+	assertBalance(t, db, wallet.Balance, aAddress)
+}
 
+func TestWalletsPagination(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	qr := resolver.Query()
+
+	// Clean and seed test data, inserted out of address order
+	addresses := []string{
+		"0xA000000000000000000000000000000000000000",
+		"0xB000000000000000000000000000000000000000",
+		"0xC000000000000000000000000000000000000000",
+	}
+	clearWallets(t, db)
+	initWallet(t, db, addresses[2], "3")
+	initWallet(t, db, addresses[0], "1")
+	initWallet(t, db, addresses[1], "2")
+
+	// Walk all pages of size 2
+	first := int32(2)
+	var after *string
+	var got []string
+	for page := 0; ; page++ {
+		if page > len(addresses) {
+			t.Fatal("Pagination did not finish")
+		}
+
+		connection, err := qr.Wallets(ctx, &first, after)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		for _, edge := range connection.Edges {
+			got = append(got, edge.Node.Address)
+		}
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		after = connection.PageInfo.EndCursor
+	}
+
+	// Check if every wallet was returned once, in address order
+	if len(got) != len(addresses) {
+		t.Fatalf("Expected %d wallets, got %v", len(addresses), got)
+	}
+	for i, address := range addresses {
+		if got[i] != address {
+			t.Errorf("Expected wallet %d to be %s, got %s", i, address, got[i])
+		}
+	}
+
+	// Page size above maximum is rejected
+	tooMany := int32(101)
+	if _, err := qr.Wallets(ctx, &tooMany, nil); err == nil {
+		t.Error("Wallets query with first > 100 did not throw error")
+	}
+}
+
+func TestTotalSupply(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	qr := resolver.Query()
+
+	// Empty table sums to zero, not an error
+	clearWallets(t, db)
+	supply, err := qr.TotalSupply(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if supply != "0.000000000000000000" {
+		t.Errorf("Expected zero supply, got %s", supply)
+	}
+
+	// Seed test data
+	initWallet(t, db, "0xA000000000000000000000000000000000000000", "1000")
+	initWallet(t, db, "0xB000000000000000000000000000000000000000", "0.000000000000000001")
+
+	supply, err = qr.TotalSupply(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if supply != "1000.000000000000000001" {
+		t.Errorf("Expected supply 1000.000000000000000001, got %s", supply)
+	}
+}
+
+func TestBalances(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+	missing := "0xc000000000000000000000000000000000000000"
+
+	// Seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+	initWallet(t, db, bAddress, "0.5")
+
+	// Request order is kept, mixed case is normalized and missing wallets are reported
+	balances, err := resolver.Query().Balances(ctx, []string{missing, "0xB000000000000000000000000000000000000000", aAddress, aAddress})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	expected := []model.WalletBalance{
+		{Address: missing, Balance: "0", Found: false},
+		{Address: bAddress, Balance: "0.500000000000000000", Found: true},
+		{Address: aAddress, Balance: "10.000000000000000000", Found: true},
+		{Address: aAddress, Balance: "10.000000000000000000", Found: true},
+	}
+	if len(balances) != len(expected) {
+		t.Fatalf("Expected %d balances, got %d", len(expected), len(balances))
+	}
+	for i, balance := range balances {
+		if *balance != expected[i] {
+			t.Errorf("Balance %d: expected %+v, got %+v", i, expected[i], *balance)
+		}
+	}
+
+	// Empty list gives an empty result
+	balances, err = resolver.Query().Balances(ctx, []string{})
+	if err != nil || len(balances) != 0 {
+		t.Errorf("Expected empty result, got %v, %v", balances, err)
+	}
+}
+
+func TestNegativeBalances(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	// Wallet table without the balance CHECK constraint, as in a DB created before it existed
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS test_wallets_unchecked (
+		address TEXT NOT NULL,
+		asset TEXT NOT NULL DEFAULT 'TOKEN',
+		token_balance NUMERIC(28,18) NOT NULL,
+		owner_id TEXT,
+		frozen BOOLEAN NOT NULL DEFAULT FALSE,
+		PRIMARY KEY (address, asset)
+	)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DROP TABLE IF EXISTS test_wallets_unchecked") })
+
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets_unchecked",
+	}
+	qr := resolver.Query()
+
+	// No negative balances
+	db.Exec("DELETE FROM test_wallets_unchecked")
+	db.Exec("INSERT INTO test_wallets_unchecked (address, token_balance) VALUES ('0xa000000000000000000000000000000000000000', 10)")
+	wallets, err := qr.NegativeBalances(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(wallets) != 0 {
+		t.Errorf("Expected no wallets, got %d", len(wallets))
+	}
+
+	// Negative balances are listed ordered by address
+	db.Exec("INSERT INTO test_wallets_unchecked (address, token_balance) VALUES ('0xc000000000000000000000000000000000000000', -1), ('0xb000000000000000000000000000000000000000', -0.5)")
+	wallets, err = qr.NegativeBalances(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(wallets) != 2 || wallets[0].Address != "0xb000000000000000000000000000000000000000" || wallets[1].Balance != "-1.000000000000000000" {
+		t.Errorf("Unexpected negative balances: %+v", wallets)
+	}
 }
@@ -0,0 +1,80 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Send query to handler as a POST request and decode the response
+func postQuery(t *testing.T, h http.Handler, query string) graphQLResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatalf("Failed to encode query: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp graphQLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestReadOnlySchema(t *testing.T) {
+	db := testutils.SetupDB(t)
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	srv := handler.New(graph.NewReadOnlyExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.AddTransport(transport.POST{})
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Queries still work
+	resp := postQuery(t, srv, `{ wallet(address: "`+aAddress+`") { address balance } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Expected no errors for wallet query, got: %v", resp.Errors)
+	}
+
+	// Mutation is unknown to read-only schema
+	resp = postQuery(t, srv, `mutation { transfer(from_address: "`+aAddress+`", to_address: "`+bAddress+`", amount: "1") { sender_balance } }`)
+	if len(resp.Errors) == 0 {
+		t.Fatal("Transfer on read-only schema did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(resp.Errors[0].Message, `does not support operation type "mutation"`) {
+		t.Fatalf("Expected unsupported mutation error, got: %s", resp.Errors[0].Message)
+	}
+
+	// Check balance was not changed
+	assertBalance(t, db, "1000", aAddress)
+}
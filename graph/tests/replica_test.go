@@ -0,0 +1,59 @@
+package graph_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+// Handle that fails every query, to show which DB a resolver used
+func closedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=invalid")
+	if err != nil {
+		t.Fatalf("Failed to open DB: %v", err)
+	}
+	db.Close()
+	return db
+}
+
+func TestReadReplicaRouting(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Reads go to ReadDB, so they work while the primary is unavailable
+	readResolver := &graph.Resolver{DB: closedDB(t), ReadDB: db, WalletTable: "test_wallets"}
+	wallet, err := readResolver.Query().Wallet(ctx, aAddress, nil)
+	if err != nil || wallet.Balance != "1000.000000000000000000" {
+		t.Fatalf("Expected wallet read from replica, got %+v, %v", wallet, err)
+	}
+	supply, err := readResolver.Query().TotalSupply(ctx, nil)
+	if err != nil || supply != "1000.000000000000000000" {
+		t.Fatalf("Expected supply read from replica, got %q, %v", supply, err)
+	}
+	balances, err := readResolver.Query().Balances(ctx, []string{aAddress})
+	if err != nil || len(balances) != 1 || !balances[0].Found {
+		t.Fatalf("Expected balances read from replica, got %v, %v", balances, err)
+	}
+
+	// Mutations stay on the primary, even with an unavailable replica
+	writeResolver := &graph.Resolver{DB: db, ReadDB: closedDB(t), WalletTable: "test_wallets"}
+	doTransfer(t, writeResolver.Mutation(), ctx, aAddress, bAddress, "10")
+	assertBalance(t, db, "990", aAddress)
+
+	// Without ReadDB, reads use the primary
+	primaryResolver := &graph.Resolver{DB: db, WalletTable: "test_wallets"}
+	if _, err := primaryResolver.Query().Wallet(ctx, bAddress, nil); err != nil {
+		t.Errorf("Expected wallet read from primary, got: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package graph_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestSandboxIsolation(t *testing.T) {
+	first, err := testutils.NewSandbox(testutils.ConnStr)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer first.Close()
+
+	second, err := testutils.NewSandbox(testutils.ConnStr)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer second.Close()
+
+	if first.Schema == second.Schema {
+		t.Fatalf("Sandboxes share schema %s", first.Schema)
+	}
+
+	ctx := context.Background()
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Seed and transfer in the first sandbox only
+	clearWallets(t, first.DB)
+	clearWallets(t, second.DB)
+	initWallet(t, first.DB, aAddress, "100")
+
+	resolver := &graph.Resolver{
+		DB:               first.DB,
+		WalletTable:      "test_wallets",
+		TransactionTable: "test_transactions",
+	}
+	if _, err := resolver.Mutation().Transfer(ctx, aAddress, bAddress, "40", nil, nil); err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+
+	// Second sandbox does not see wallets nor transactions of the first one
+	var wallets, transactions int
+	if err := second.DB.QueryRow("SELECT COUNT(*) FROM test_wallets").Scan(&wallets); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if err := second.DB.QueryRow("SELECT COUNT(*) FROM test_transactions").Scan(&transactions); err != nil {
+		t.Fatalf("Failed to count transactions: %v", err)
+	}
+	if wallets != 0 || transactions != 0 {
+		t.Errorf("Expected empty second sandbox, got %d wallets and %d transactions", wallets, transactions)
+	}
+
+	// Shared test DB is not touched either
+	var count int
+	if err := testutils.DB.QueryRow("SELECT COUNT(*) FROM test_wallets WHERE address = $1", strings.ToLower(bAddress)).Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Sandbox transfer leaked into shared test DB")
+	}
+}
@@ -0,0 +1,51 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestServiceTransfer(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	service := (&graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}).Service()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Transfer without GraphQL creates recipient and returns both balances
+	result, err := service.Transfer(ctx, aAddress, bAddress, "250")
+	if err != nil {
+		t.Fatalf("Service transfer failed: %v", err)
+	}
+	if result.SenderBalance != "750.000000000000000000" || result.RecipientBalance != "250.000000000000000000" {
+		t.Errorf("Unexpected balances in result: %+v", result)
+	}
+	assertBalance(t, db, "750", aAddress)
+	assertBalance(t, db, "250", bAddress)
+
+	// Expected balance condition
+	expected := "1000"
+	_, _, err = service.TransferWithOptions(ctx, aAddress, bAddress, "1", graph.TransferOptions{ExpectedSenderBalance: &expected})
+	if err == nil {
+		t.Fatal("Transfer with stale expected balance did not throw error")
+	}
+
+	// Same errors as the transfer mutation
+	if _, err := service.Transfer(ctx, aAddress, bAddress, "10000"); !errors.Is(err, graph.ErrInsufficientBalance) {
+		t.Errorf("Expected ErrInsufficientBalance, got: %v", err)
+	}
+	assertBalance(t, db, "750", aAddress)
+}
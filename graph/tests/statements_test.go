@@ -0,0 +1,105 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestPreparedStatements(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	for _, strategy := range []graph.LockStrategy{graph.LockAdvisory, graph.LockRow, graph.LockOptimistic} {
+		resolver := &graph.Resolver{
+			DB:           db,
+			WalletTable:  "test_wallets",
+			LockStrategy: strategy,
+		}
+		if err := resolver.Prepare(ctx); err != nil {
+			t.Fatalf("%s: prepare failed: %v", strategy, err)
+		}
+
+		// Clean and seed test data
+		clearWallets(t, db)
+		initWallet(t, db, aAddress, "1000")
+
+		// Prepared statements give the same results as inline SQL, new recipient included
+		mutation := resolver.Mutation()
+		doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+		doTransfer(t, mutation, ctx, bAddress, aAddress, "40")
+		assertBalance(t, db, "940", aAddress)
+		assertBalance(t, db, "60", bAddress)
+
+		// Balance query reads through the prepared balance statement
+		balance, err := resolver.Query().Balance(ctx, aAddress)
+		if err != nil || balance.Balance != "940" {
+			t.Errorf("%s: expected balance 940, got %+v, %v", strategy, balance, err)
+		}
+
+		if err := resolver.Close(); err != nil {
+			t.Errorf("%s: close failed: %v", strategy, err)
+		}
+
+		// After Close, transfers go back to inline SQL
+		doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+		assertBalance(t, db, "939", aAddress)
+	}
+
+	// Missing table cannot be prepared
+	resolver := &graph.Resolver{DB: db, WalletTable: "missing_wallets"}
+	if err := resolver.Prepare(ctx); err == nil {
+		t.Error("Expected prepare error for missing table")
+	}
+}
+
+func BenchmarkTransferPreparedStatements(b *testing.B) {
+	db := testutils.SetupDB(b)
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	for _, prepared := range []bool{false, true} {
+		name := "inline"
+		if prepared {
+			name = "prepared"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			resolver := &graph.Resolver{
+				DB:          db,
+				WalletTable: "test_wallets",
+			}
+			ctx := context.Background()
+			if prepared {
+				if err := resolver.Prepare(ctx); err != nil {
+					b.Fatalf("Prepare failed: %v", err)
+				}
+				defer resolver.Close()
+			}
+			mutation := resolver.Mutation()
+
+			// Clean and seed test data
+			clearWallets(b, db)
+			initWallet(b, db, aAddress, "1000000")
+			initWallet(b, db, bAddress, "1000000")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				from, to := aAddress, bAddress
+				if i%2 == 1 {
+					from, to = bAddress, aAddress
+				}
+				if _, err := mutation.Transfer(ctx, from, to, "0.000000000000000001", nil, nil); err != nil {
+					b.Fatalf("Transfer %s → %s failed: %v", from, to, err)
+				}
+			}
+		})
+	}
+}
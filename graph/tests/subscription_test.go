@@ -0,0 +1,71 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"token_transfer/graph"
+	"token_transfer/graph/model"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestBalanceChangedSubscription(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+
+	// Subscribe to both wallets; recipient does not exist yet
+	senderUpdates, err := resolver.Subscription().BalanceChanged(ctx, aAddress)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	recipientUpdates, err := resolver.Subscription().BalanceChanged(ctx, "0xB000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	doTransfer(t, resolver.Mutation(), ctx, aAddress, bAddress, "10")
+
+	// Check if both wallets received their new balance
+	for _, c := range []struct {
+		updates <-chan *model.BalanceChange
+		address string
+		balance string
+	}{
+		{senderUpdates, aAddress, "90.000000000000000000"},
+		{recipientUpdates, bAddress, "10.000000000000000000"},
+	} {
+		select {
+		case update := <-c.updates:
+			if update.Address != c.address || update.Balance != c.balance {
+				t.Errorf("Expected %s balance %s, got %+v", c.address, c.balance, update)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("No balance update for %s", c.address)
+		}
+	}
+
+	// Subscription ends when client disconnects
+	cancel()
+	select {
+	case _, ok := <-senderUpdates:
+		if ok {
+			t.Error("Expected no update after disconnect")
+		}
+	case <-time.After(time.Second):
+		t.Error("Subscription channel not closed after disconnect")
+	}
+}
@@ -0,0 +1,69 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+)
+
+func TestCustomTableConfig(t *testing.T) {
+	db := testutils.SetupDB(t)
+	ctx := context.Background()
+
+	// Existing schema with its own column names
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS test_accounts (
+		account_address TEXT NOT NULL,
+		asset TEXT NOT NULL DEFAULT 'TOKEN',
+		amount NUMERIC(28,18) NOT NULL CHECK (amount >= 0),
+		owner_id TEXT,
+		frozen BOOLEAN NOT NULL DEFAULT FALSE,
+		version BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (account_address, asset)
+	)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DROP TABLE IF EXISTS test_accounts") })
+
+	resolver := &graph.Resolver{
+		DB:     db,
+		Tables: graph.TableConfig{Wallets: "test_accounts", AddressCol: "account_address", BalanceCol: "amount"},
+	}
+	if err := resolver.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	db.Exec("DELETE FROM test_accounts")
+	if _, err := db.Exec("INSERT INTO test_accounts (account_address, amount) VALUES ($1, 10)", aAddress); err != nil {
+		t.Fatalf("Failed to insert wallet: %v", err)
+	}
+
+	// Transfer creates the recipient in the configured table
+	result, err := resolver.Mutation().Transfer(ctx, aAddress, bAddress, "2.5", nil, nil)
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if result.SenderBalance != "7.500000000000000000" || result.RecipientBalance != "2.500000000000000000" {
+		t.Errorf("Unexpected transfer result: %+v", result)
+	}
+
+	wallet, err := resolver.Query().Wallet(ctx, bAddress, nil)
+	if err != nil {
+		t.Fatalf("Expected wallet, got: %v", err)
+	}
+	if wallet.Address != bAddress || wallet.Balance != "2.500000000000000000" {
+		t.Errorf("Unexpected wallet: %+v", wallet)
+	}
+
+	supply, err := resolver.Query().TotalSupply(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if supply != "10.000000000000000000" {
+		t.Errorf("Expected supply 10.000000000000000000, got %s", supply)
+	}
+}
@@ -7,22 +7,14 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// Connection to the sandbox of the current test run
 var DB *sql.DB
 
-func ResetDatabaseState(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM wallets")
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec(`
-		INSERT INTO wallets (address, token_balance)
-		VALUES ($1, $2::numeric)
-	`, "0x0000000000000000000000000000000000000000", "1000000")
-	return err
-}
+// Connection string of the test DB, used to create extra sandboxes
+var ConnStr string
 
 // Returns already created DB instance
-func SetupDB(t *testing.T) *sql.DB {
+func SetupDB(t testing.TB) *sql.DB {
 	t.Helper()
 	if DB == nil {
 		t.Fatal("DB is not initialized, do TestMain first.")
@@ -0,0 +1,115 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// Tables copied into every sandbox
+var sandboxTables = []string{"test_wallets", "test_transactions", "test_transactions_head", "test_treasury_audit", "test_allowances"}
+
+// Sandbox tables that start with the rows of their public copy
+var seededTables = []string{"test_transactions_head"}
+
+// Private schema with its own copy of test tables
+// DB has search_path set to the schema, so unqualified table names resolve inside it
+// and test runs never touch each other or production tables
+type Sandbox struct {
+	Schema string
+	DB     *sql.DB
+
+	admin *sql.DB
+}
+
+// Create sandbox schema with random suffix; connStr is a key=value connection string
+func NewSandbox(connStr string) (*Sandbox, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	schema := "sandbox_" + hex.EncodeToString(suffix)
+
+	admin, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	sandbox := &Sandbox{Schema: schema, admin: admin}
+	if err := sandbox.createTables(); err != nil {
+		_ = sandbox.Close()
+		return nil, err
+	}
+
+	sandbox.DB, err = sql.Open("postgres", fmt.Sprintf("%s search_path=%s", connStr, schema))
+	if err != nil {
+		_ = sandbox.Close()
+		return nil, err
+	}
+
+	return sandbox, nil
+}
+
+func (s *Sandbox) createTables() error {
+	if _, err := s.admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", s.Schema)); err != nil {
+		return err
+	}
+
+	for _, table := range sandboxTables {
+		query := fmt.Sprintf("CREATE TABLE %s.%s (LIKE public.%s INCLUDING ALL)", s.Schema, table, table)
+		if _, err := s.admin.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range seededTables {
+		query := fmt.Sprintf("INSERT INTO %s.%s SELECT * FROM public.%s", s.Schema, table, table)
+		if _, err := s.admin.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	// Serial columns copied by LIKE still use sequences from public schema; give them their own
+	rows, err := s.admin.Query(`SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = $1 AND column_default LIKE 'nextval(%'`, s.Schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var serials [][2]string
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return err
+		}
+		serials = append(serials, [2]string{table, column})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, serial := range serials {
+		table, column := serial[0], serial[1]
+		sequence := fmt.Sprintf("%s.%s_%s_seq", s.Schema, table, column)
+		query := fmt.Sprintf(`CREATE SEQUENCE %s OWNED BY %s.%s.%s;
+			ALTER TABLE %s.%s ALTER COLUMN %s SET DEFAULT nextval('%s')`,
+			sequence, s.Schema, table, column, s.Schema, table, column, sequence)
+		if _, err := s.admin.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Drop sandbox schema with all its tables and close connections
+func (s *Sandbox) Close() error {
+	if s.DB != nil {
+		_ = s.DB.Close()
+	}
+	_, err := s.admin.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", s.Schema))
+	_ = s.admin.Close()
+	return err
+}
@@ -0,0 +1,58 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransferSpans(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	doTransfer(t, resolver.Mutation(), ctx, aAddress, bAddress, "1")
+
+	// Child spans belong to the Transfer span
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["Transfer"]
+	if !ok {
+		t.Fatal("Transfer span not recorded")
+	}
+	for _, name := range []string{"lockWallets", "getTransferWallets", "updateBalances"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("%s span not recorded", name)
+			continue
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s span is not a child of Transfer span", name)
+		}
+	}
+}
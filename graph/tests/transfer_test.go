@@ -7,8 +7,10 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"token_transfer/graph"
+	"token_transfer/graph/model"
 	"token_transfer/graph/tests/testutils"
 
 	_ "github.com/lib/pq"
@@ -139,7 +141,7 @@ func TestTransferNoRowsError(t *testing.T) {
 	fromAddress := cAddress
 	toAddress := aAddress
 	amount := "100"
-	_, err := mutation.Transfer(ctx, fromAddress, toAddress, amount)
+	_, err := mutation.Transfer(ctx, fromAddress, toAddress, amount, nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer from nonexistent sender did not throw error")
@@ -149,6 +151,152 @@ func TestTransferNoRowsError(t *testing.T) {
 	if !errors.Is(err, sql.ErrNoRows) {
 		t.Fatalf("Expected 'no rows' error, got: %v", err)
 	}
+	// Clients get a readable message
+	if err.Error() != "sender wallet does not exist: "+strings.ToLower(cAddress) {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+func TestTransferNoRowsErrorBothMissing(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Clean test data, neither wallet exists
+	clearWallets(t, db)
+
+	_, err := mutation.Transfer(ctx, cAddress, bAddress, "100", nil, nil)
+	// Check error type
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected 'no rows' error, got: %v", err)
+	}
+
+	// Check if recipient was not created by rejected transfer
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_wallets WHERE address = $1", strings.ToLower(bAddress)).Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected recipient wallet not to be created, got %d rows", count)
+	}
+}
+
+func TestTransferAutoCreateSender(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Strict mode (default): nonexistent sender is rejected
+	strictResolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	_, err := strictResolver.Mutation().Transfer(ctx, cAddress, aAddress, "100", nil, nil)
+	// Check error type
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected 'no rows' error in strict mode, got: %v", err)
+	}
+
+	// Auto-create mode: sender is created with default balance, then transfer proceeds
+	autoResolver := &graph.Resolver{
+		DB:                   db,
+		WalletTable:          "test_wallets",
+		AutoCreateSender:     true,
+		DefaultSenderBalance: decimal.RequireFromString("500"),
+	}
+
+	doTransfer(t, autoResolver.Mutation(), ctx, cAddress, aAddress, "100")
+
+	// Check balances
+	assertBalance(t, db, "400", cAddress)
+	assertBalance(t, db, "1100", aAddress)
+}
+
+func TestTransferDustToNewWallet(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                 db,
+		WalletTable:        "test_wallets",
+		NewWalletMinAmount: decimal.RequireFromString("0.01"),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Dust to a new wallet is rejected
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "0.001", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Dust transfer to new wallet did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "amount below minimum for new wallet") {
+		t.Fatalf("Expected 'amount below minimum for new wallet' error, got: %v", err)
+	}
+
+	// Check if wallet was not created
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_wallets WHERE address = $1", strings.ToLower(bAddress)).Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no wallet for %s, found %d", bAddress, count)
+	}
+
+	assertBalance(t, db, "10", aAddress)
+}
+
+func TestTransferDustToExistingWallet(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                 db,
+		WalletTable:        "test_wallets",
+		NewWalletMinAmount: decimal.RequireFromString("0.01"),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+	initWallet(t, db, bAddress, "1")
+
+	// Dust top-up of an existing wallet is allowed
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "0.001")
+
+	// Check balances
+	assertBalance(t, db, "9.999", aAddress)
+	assertBalance(t, db, "1.001", bAddress)
 }
 
 func TestTransferReducesBalanceToZero(t *testing.T) {
@@ -204,7 +352,7 @@ func TestTransferInsufficientBalanceError(t *testing.T) {
 	// Transfer
 	fromAddress := aAddress
 	toAddress := bAddress
-	_, err := mutation.Transfer(ctx, fromAddress, toAddress, "1100")
+	_, err := mutation.Transfer(ctx, fromAddress, toAddress, "1100", nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer with insufficient balance did not throw error")
@@ -214,6 +362,9 @@ func TestTransferInsufficientBalanceError(t *testing.T) {
 	if !strings.Contains(err.Error(), "insufficient balance") {
 		t.Fatalf("Expected 'insufficient balance' error, got: %v", err)
 	}
+	if !errors.Is(err, graph.ErrInsufficientBalance) {
+		t.Fatalf("Expected error to wrap ErrInsufficientBalance, got: %v", err)
+	}
 }
 
 func TestTransferAfterInsufficientBalance(t *testing.T) {
@@ -239,7 +390,7 @@ func TestTransferAfterInsufficientBalance(t *testing.T) {
 	toAddress := bAddress
 	amount := "11"
 
-	_, err := mutation.Transfer(ctx, fromAddress, toAddress, amount)
+	_, err := mutation.Transfer(ctx, fromAddress, toAddress, amount, nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer with insufficient balance did not throw error")
@@ -261,6 +412,79 @@ func TestTransferAfterInsufficientBalance(t *testing.T) {
 
 }
 
+func TestTransferExpectedSenderBalanceMatches(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Client reads balance before transfer
+	expectedBalance := getBalance(t, db, aAddress)
+
+	// Transfer with expected balance equal to the actual one
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "100", &expectedBalance, nil)
+	if err != nil {
+		t.Fatalf("Transfer with matching expected balance failed: %v", err)
+	}
+
+	// Check balances
+	assertBalance(t, db, "900", aAddress)
+	assertBalance(t, db, "100", bAddress)
+}
+
+func TestTransferExpectedSenderBalanceChanged(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+	cAddress := "0xC000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Client reads balance before transfer
+	expectedBalance := getBalance(t, db, aAddress)
+
+	// Concurrent modification of sender balance: A -> C
+	doTransfer(t, mutation, ctx, aAddress, cAddress, "100")
+
+	// Transfer with outdated expected balance
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "100", &expectedBalance, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer with outdated expected balance did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "balance changed, please retry") {
+		t.Fatalf("Expected 'balance changed, please retry' error, got: %v", err)
+	}
+
+	// Check balances were not changed by rejected transfer
+	assertBalance(t, db, "900", aAddress)
+	assertBalance(t, db, "100", cAddress)
+}
+
 func TestValidateTokenAmount_InvalidDecimal(t *testing.T) {
 	db := testutils.SetupDB(t)
 
@@ -281,7 +505,7 @@ func TestValidateTokenAmount_InvalidDecimal(t *testing.T) {
 
 	// Transfer
 	invalidAmount := "abc123"
-	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount)
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount, nil, nil)
 
 	// Check if transfer throws error
 	if err == nil {
@@ -313,7 +537,7 @@ func TestValidateAmount_TooManyDecimalPlaces(t *testing.T) {
 
 	// Transfer
 	invalidAmount := "1.1234567890123456789" // >18 decimal places
-	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount)
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount, nil, nil)
 
 	// Check if transfer throws error
 	if err == nil {
@@ -346,7 +570,7 @@ func TestValidateAmount_TooManyDigits(t *testing.T) {
 
 	// Transfer
 	invalidAmount := "12345678901234567890123456789.0" // >28 digits
-	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount)
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount, nil, nil)
 
 	// Check if transfer throws error
 	if err == nil {
@@ -379,7 +603,7 @@ func TestValidateAmount_AmountBelowZero(t *testing.T) {
 
 	// Transfer
 	invalidAmount := "-12"
-	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount)
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, invalidAmount, nil, nil)
 
 	// Check if transfer throws error
 	if err == nil {
@@ -389,6 +613,9 @@ func TestValidateAmount_AmountBelowZero(t *testing.T) {
 	if !strings.Contains(err.Error(), "amount must be greater than zero") {
 		t.Fatalf("Expected 'amount must be greater than zero' error, got: %v", err)
 	}
+	if !errors.Is(err, graph.ErrInvalidAmount) {
+		t.Fatalf("Expected error to wrap ErrInvalidAmount, got: %v", err)
+	}
 
 }
 
@@ -411,7 +638,7 @@ func TestValidateAddressess_SameAddress(t *testing.T) {
 	initWallet(t, db, aAddress, "10")
 
 	// Transfer
-	_, err := mutation.Transfer(ctx, aAddress, smallAAddress, "1")
+	_, err := mutation.Transfer(ctx, aAddress, smallAAddress, "1", nil, nil)
 
 	// Check if transfer throws error
 	if err == nil {
@@ -421,6 +648,9 @@ func TestValidateAddressess_SameAddress(t *testing.T) {
 	if !strings.Contains(err.Error(), "sender and recipient addresses must be different") {
 		t.Fatalf("Expected 'sender and recipient addresses must be different' error, got: %v", err)
 	}
+	if !errors.Is(err, graph.ErrSameAddress) {
+		t.Fatalf("Expected error to wrap ErrSameAddress, got: %v", err)
+	}
 
 }
 
@@ -443,7 +673,7 @@ func TestValidateEthereumAddress(t *testing.T) {
 
 	// Address is too short
 	wrongAddress := "0xa00000000000000000000000000000000000000"
-	_, err := mutation.Transfer(ctx, aAddress, wrongAddress, "1")
+	_, err := mutation.Transfer(ctx, aAddress, wrongAddress, "1", nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer with invalid amount did not throw error")
@@ -455,7 +685,7 @@ func TestValidateEthereumAddress(t *testing.T) {
 
 	// Address does not start with '0x'
 	wrongAddress = "00a000000000000000000000000000000000000000"
-	_, err = mutation.Transfer(ctx, aAddress, wrongAddress, "1")
+	_, err = mutation.Transfer(ctx, aAddress, wrongAddress, "1", nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer with invalid amount did not throw error")
@@ -467,7 +697,7 @@ func TestValidateEthereumAddress(t *testing.T) {
 
 	// Address has letters other than A-F
 	wrongAddress = "0xG000000000000000000000000000000000000000"
-	_, err = mutation.Transfer(ctx, aAddress, wrongAddress, "1")
+	_, err = mutation.Transfer(ctx, aAddress, wrongAddress, "1", nil, nil)
 	// Check if transfer throws error
 	if err == nil {
 		t.Fatal("Transfer with invalid amount did not throw error")
@@ -476,9 +706,137 @@ func TestValidateEthereumAddress(t *testing.T) {
 	if !strings.Contains(err.Error(), "invalid Ethereum address format") {
 		t.Fatalf("Expected 'invalid Ethereum address format' error, got: %v", err)
 	}
+	if !errors.Is(err, graph.ErrInvalidAddress) {
+		t.Fatalf("Expected error to wrap ErrInvalidAddress, got: %v", err)
+	}
 
 }
 
+func TestTransferScaled(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Transfer 15 * 10^-1 = 1.5 tokens
+	senderBalance, err := mutation.TransferScaled(ctx, aAddress, bAddress, "15", 1)
+	if err != nil {
+		t.Fatalf("Scaled transfer failed: %v", err)
+	}
+
+	// Check returned sender balance
+	if senderBalance != "8.500000000000000000" {
+		t.Errorf("Expected sender balance 8.500000000000000000, got %s", senderBalance)
+	}
+
+	// Check balances
+	assertBalance(t, db, "8.5", aAddress)
+	assertBalance(t, db, "1.5", bAddress)
+}
+
+func TestTransferUnits(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+	bAddress := "0xB000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Transfer a single base unit, the smallest amount NUMERIC(28,18) holds
+	result, err := mutation.TransferUnits(ctx, aAddress, bAddress, "1")
+	if err != nil {
+		t.Fatalf("Units transfer failed: %v", err)
+	}
+	if result.Amount != "0.000000000000000001" {
+		t.Errorf("Expected amount 0.000000000000000001, got %s", result.Amount)
+	}
+	if result.SenderBalance != "9.999999999999999999" {
+		t.Errorf("Expected sender balance 9.999999999999999999, got %s", result.SenderBalance)
+	}
+
+	// Transfer 1.5 tokens as base units
+	if _, err := mutation.TransferUnits(ctx, aAddress, bAddress, "1500000000000000000"); err != nil {
+		t.Fatalf("Units transfer failed: %v", err)
+	}
+
+	// Check balances
+	assertBalance(t, db, "8.499999999999999999", aAddress)
+	assertBalance(t, db, "1.500000000000000001", bAddress)
+
+	// Units must be an integer
+	_, err = mutation.TransferUnits(ctx, aAddress, bAddress, "1.5")
+	if err == nil || !strings.Contains(err.Error(), "units must be an integer") {
+		t.Fatalf("Expected 'units must be an integer' error, got: %v", err)
+	}
+	assertBalance(t, db, "8.499999999999999999", aAddress)
+}
+
+func TestTransferScaled_InvalidUnits(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Units must be an integer
+	_, err := mutation.TransferScaled(ctx, aAddress, bAddress, "1.5", 1)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Scaled transfer with fractional units did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "units must be an integer") {
+		t.Fatalf("Expected 'units must be an integer' error, got: %v", err)
+	}
+
+	// Decimals out of NUMERIC(28,18) scale
+	_, err = mutation.TransferScaled(ctx, aAddress, bAddress, "15", 19)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Scaled transfer with 19 decimals did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "decimals must be between 0 and 18") {
+		t.Fatalf("Expected 'decimals must be between 0 and 18' error, got: %v", err)
+	}
+
+	// Check balance was not changed
+	assertBalance(t, db, "10", aAddress)
+}
+
 func TestCyclicTransfer(t *testing.T) {
 	db := testutils.SetupDB(t)
 
@@ -559,7 +917,7 @@ func TestRaceConditionSameWalletConcurrentTransfers(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		<-start // barrier up
-		_, err := mutation.Transfer(ctx, aAddress, bAddress, "4")
+		_, err := mutation.Transfer(ctx, aAddress, bAddress, "4", nil, nil)
 		if err != nil && !strings.Contains(err.Error(), "insufficient balance") {
 			t.Errorf("A -> B failed unexpectedly: %v", err)
 		}
@@ -570,7 +928,7 @@ func TestRaceConditionSameWalletConcurrentTransfers(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		<-start // barrier up
-		_, err := mutation.Transfer(ctx, aAddress, cAddress, "7")
+		_, err := mutation.Transfer(ctx, aAddress, cAddress, "7", nil, nil)
 		if err != nil && !strings.Contains(err.Error(), "insufficient balance") {
 			t.Errorf("A -> C failed unexpectedly: %v", err)
 		}
@@ -580,7 +938,7 @@ func TestRaceConditionSameWalletConcurrentTransfers(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		<-start // barrier up
-		_, err := mutation.Transfer(ctx, dAddress, aAddress, "1")
+		_, err := mutation.Transfer(ctx, dAddress, aAddress, "1", nil, nil)
 		if err != nil {
 			t.Errorf("D -> A failed unexpectedly: %v", err)
 		}
@@ -678,3 +1036,208 @@ func TestManyConcurrentTransfersDeadlock(t *testing.T) {
 	assertBalance(t, db, expectedA, aAddress)
 	assertBalance(t, db, expectedB, bAddress)
 }
+
+func TestTransferMixedCaseAddresses(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bLower := "0xb00000000000000000000000000000000000000f"
+	bUpper := "0xB00000000000000000000000000000000000000F"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+
+	// Both case variants credit the same wallet, and sender may be given in any case too
+	doTransfer(t, mutation, ctx, aAddress, bUpper, "10")
+	doTransfer(t, mutation, ctx, "0xA000000000000000000000000000000000000000", bLower, "5")
+
+	// Check if only one row exists for recipient, keyed by lowercase address
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_wallets WHERE lower(address) = $1", bLower).Scan(&count); err != nil {
+		t.Fatalf("Failed to count wallets: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 recipient wallet, got %d", count)
+	}
+	assertBalance(t, db, "15", bLower)
+	assertBalance(t, db, "85", aAddress)
+
+	// Wallet query accepts any case
+	wallet, err := resolver.Query().Wallet(ctx, bUpper, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if wallet.Address != bLower {
+		t.Errorf("Expected address %s, got %s", bLower, wallet.Address)
+	}
+}
+
+func TestTransferRateLimit(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		TransferRateLimit: 3,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+
+	// N transfers within the limit succeed
+	for i := 0; i < resolver.TransferRateLimit; i++ {
+		doTransfer(t, mutation, ctx, aAddress, bAddress, "1")
+	}
+
+	// N+1st transfer is rejected
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "1", nil, nil)
+	if !errors.Is(err, graph.ErrRateLimitExceeded) {
+		t.Fatalf("Expected 'rate limit exceeded' error, got: %v", err)
+	}
+	assertBalance(t, db, "97", aAddress)
+}
+
+func TestTransferResult(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:          db,
+		WalletTable: "test_wallets",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "100")
+	initWallet(t, db, bAddress, "5")
+
+	before := time.Now()
+	result, err := mutation.Transfer(ctx, aAddress, bAddress, "12.5", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Without history, the commit time is returned
+	if result.Timestamp.Before(before) || result.Timestamp.After(time.Now()) || result.Timestamp.Location() != time.UTC {
+		t.Errorf("Expected UTC commit timestamp, got %v", result.Timestamp)
+	}
+
+	// Both sides of the transfer are returned
+	expected := model.TransferResult{
+		FromAddress:      aAddress,
+		ToAddress:        bAddress,
+		SenderBalance:    "87.500000000000000000",
+		RecipientBalance: "17.500000000000000000",
+		Amount:           "12.500000000000000000",
+		Timestamp:        result.Timestamp,
+	}
+	if *result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *result)
+	}
+	assertBalance(t, db, result.RecipientBalance, bAddress)
+
+	// With history, the stored timestamp is returned
+	clearTransactions(t, db)
+	resolver.TransactionTable = "test_transactions"
+	result, err = mutation.Transfer(ctx, aAddress, bAddress, "1", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	var createdAt time.Time
+	if err := db.QueryRow("SELECT created_at FROM test_transactions").Scan(&createdAt); err != nil {
+		t.Fatalf("Failed to read transaction: %v", err)
+	}
+	if !result.Timestamp.Equal(createdAt) {
+		t.Errorf("Expected timestamp %v from transaction log, got %v", createdAt, result.Timestamp)
+	}
+}
+
+func TestTransferMaxAmount(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		MaxTransferAmount: decimal.RequireFromString("100"),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "1000")
+
+	// Exactly at the cap succeeds
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "100")
+
+	// Smallest amount over the cap is rejected
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "100.000000000000000001", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "amount exceeds maximum allowed transfer") {
+		t.Fatalf("Expected 'amount exceeds maximum allowed transfer' error, got: %v", err)
+	}
+	if !errors.Is(err, graph.ErrInvalidAmount) {
+		t.Errorf("Expected error to match ErrInvalidAmount, got: %v", err)
+	}
+
+	// Check balances
+	assertBalance(t, db, "900", aAddress)
+	assertBalance(t, db, "100", bAddress)
+}
+
+func TestTransferMinAmount(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		MinTransferAmount: decimal.RequireFromString("0.01"),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xa000000000000000000000000000000000000000"
+	bAddress := "0xb000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, aAddress, "10")
+
+	// Dust is rejected
+	_, err := mutation.Transfer(ctx, aAddress, bAddress, "0.000000000000000001", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "amount below minimum") {
+		t.Fatalf("Expected 'amount below minimum' error, got: %v", err)
+	}
+
+	// Exactly the minimum succeeds
+	doTransfer(t, mutation, ctx, aAddress, bAddress, "0.01")
+
+	// Check balances
+	assertBalance(t, db, "9.99", aAddress)
+	assertBalance(t, db, "0.01", bAddress)
+}
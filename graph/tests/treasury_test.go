@@ -0,0 +1,203 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/graph/tests/testutils"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+)
+
+const treasuryAddress = "0x0000000000000000000000000000000000000000"
+
+func TestTreasuryProtected_TransferBlocked(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		TreasuryAddress:   treasuryAddress,
+		TreasuryProtected: true,
+		AuditTable:        "test_treasury_audit",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, treasuryAddress, "1000000")
+
+	// Ordinary transfer from protected treasury
+	_, err := mutation.Transfer(ctx, treasuryAddress, aAddress, "100", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer from protected treasury did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "transfers from treasury require treasuryTransfer") {
+		t.Fatalf("Expected 'transfers from treasury require treasuryTransfer' error, got: %v", err)
+	}
+
+	assertBalance(t, db, "1000000", treasuryAddress)
+}
+
+func TestTreasuryTransfer(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := graph.WithActor(context.Background(), "ops-team")
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		TreasuryAddress:   treasuryAddress,
+		TreasuryProtected: true,
+		AuditTable:        "test_treasury_audit",
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTreasuryAudit(t, db)
+	initWallet(t, db, treasuryAddress, "1000000")
+
+	// Distribution with a reason succeeds
+	treasuryBalance, err := mutation.TreasuryTransfer(ctx, aAddress, "100", "community grant")
+	if err != nil {
+		t.Fatalf("Treasury transfer failed: %v", err)
+	}
+	if treasuryBalance != "999900.000000000000000000" {
+		t.Errorf("Expected treasury balance 999900.000000000000000000, got %s", treasuryBalance)
+	}
+
+	// Check balances
+	assertBalance(t, db, "999900", treasuryAddress)
+	assertBalance(t, db, "100", aAddress)
+
+	// Check audit entry
+	var actor, toAddress, reason string
+	err = db.QueryRow("SELECT actor, to_address, reason FROM test_treasury_audit").Scan(&actor, &toAddress, &reason)
+	if err != nil {
+		t.Fatalf("Failed to read audit entry: %v", err)
+	}
+	if actor != "ops-team" || toAddress != strings.ToLower(aAddress) || reason != "community grant" {
+		t.Errorf("Unexpected audit entry: actor=%s to=%s reason=%s", actor, toAddress, reason)
+	}
+
+	// Distribution without a reason is rejected
+	_, err = mutation.TreasuryTransfer(ctx, aAddress, "100", "  ")
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Treasury transfer without reason did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "reason is required") {
+		t.Fatalf("Expected 'reason is required' error, got: %v", err)
+	}
+
+	assertBalance(t, db, "999900", treasuryAddress)
+}
+
+func TestTreasuryInsufficientBalance(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:              db,
+		WalletTable:     "test_wallets",
+		TreasuryAddress: treasuryAddress,
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	initWallet(t, db, treasuryAddress, "1000")
+
+	// Draining treasury to exactly zero is allowed
+	doTransfer(t, mutation, ctx, treasuryAddress, aAddress, "1000")
+	assertBalance(t, db, "0", treasuryAddress)
+
+	before := testutil.ToFloat64(graph.TreasuryInsufficientBalance)
+
+	// One unit beyond zero
+	_, err := mutation.Transfer(ctx, treasuryAddress, aAddress, "0.000000000000000001", nil, nil)
+	// Check if transfer throws error
+	if err == nil {
+		t.Fatal("Transfer from empty treasury did not throw error")
+	}
+	// Check error type
+	if !strings.Contains(err.Error(), "treasury insufficient balance") {
+		t.Fatalf("Expected 'treasury insufficient balance' error, got: %v", err)
+	}
+	if !errors.Is(err, graph.ErrInsufficientBalance) {
+		t.Fatalf("Expected error to wrap ErrInsufficientBalance, got: %v", err)
+	}
+
+	// Check if near-miss was counted
+	if after := testutil.ToFloat64(graph.TreasuryInsufficientBalance); after != before+1 {
+		t.Errorf("Expected treasury near-miss counter to be %v, got %v", before+1, after)
+	}
+
+	assertBalance(t, db, "0", treasuryAddress)
+}
+
+func TestTreasuryTransfer_SharesTransferChecks(t *testing.T) {
+	db := testutils.SetupDB(t)
+
+	ctx := context.Background()
+	resolver := &graph.Resolver{
+		DB:                db,
+		WalletTable:       "test_wallets",
+		TreasuryAddress:   treasuryAddress,
+		AuditTable:        "test_treasury_audit",
+		MaxTransferAmount: decimal.NewFromInt(500),
+	}
+
+	mutation := resolver.Mutation()
+
+	aAddress := "0xA000000000000000000000000000000000000000"
+
+	// Clean and seed test data
+	clearWallets(t, db)
+	clearTreasuryAudit(t, db)
+	initWallet(t, db, treasuryAddress, "1000")
+
+	// Amount limits apply like to any transfer
+	_, err := mutation.TreasuryTransfer(ctx, aAddress, "600", "grant")
+	if err == nil || !strings.Contains(err.Error(), "amount exceeds maximum allowed transfer") {
+		t.Fatalf("Expected 'amount exceeds maximum allowed transfer' error, got: %v", err)
+	}
+
+	// Successful distribution is counted like a transfer
+	before := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("success"))
+	if _, err := mutation.TreasuryTransfer(ctx, aAddress, "100", "grant"); err != nil {
+		t.Fatalf("Treasury transfer failed: %v", err)
+	}
+	if after := testutil.ToFloat64(graph.TransfersTotal.WithLabelValues("success")); after != before+1 {
+		t.Errorf("Expected success counter to be %v, got %v", before+1, after)
+	}
+
+	// Only the distribution that went through is audited
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM test_treasury_audit").Scan(&count); err != nil {
+		t.Fatalf("Failed to count audit entries: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 audit entry, got %d", count)
+	}
+
+	assertBalance(t, db, "900", treasuryAddress)
+	assertBalance(t, db, "100", aAddress)
+}
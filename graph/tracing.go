@@ -0,0 +1,19 @@
+package graph
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Spans go to the global tracer provider; without one set up they are no-ops
+var tracer = otel.Tracer("token_transfer/graph")
+
+// End span, marking it as failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
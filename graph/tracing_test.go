@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransferSpanRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	resolver := &Resolver{}
+
+	// Rejected by validation, before any DB call
+	_, err := resolver.Mutation().Transfer(context.Background(), "0x123", "0xB000000000000000000000000000000000000000", "1", nil, nil)
+	if err == nil {
+		t.Fatal("Transfer with invalid address did not throw error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "Transfer" {
+		t.Fatalf("Expected one Transfer span, got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("Expected error status, got %v", spans[0].Status())
+	}
+	if len(spans[0].Events()) == 0 || spans[0].Events()[0].Name != "exception" {
+		t.Errorf("Expected recorded error event, got %v", spans[0].Events())
+	}
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type actorContextKey struct{}
+
+// Attach identity of the caller, recorded in audit entries
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// Identity of the caller, empty when request is not authenticated
+func actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// Check if address is the treasury wallet
+func (r *Resolver) isTreasury(address string) bool {
+	return r.TreasuryAddress != "" && strings.EqualFold(address, r.TreasuryAddress)
+}
+
+// Save who moved funds out of treasury and why; actor is NULL for unauthenticated calls
+func (r *Resolver) recordTreasuryAudit(ctx context.Context, tx *sql.Tx, toAddress, amount, reason string) error {
+	var actor sql.NullString
+	if value := actorFromContext(ctx); value != "" {
+		actor = sql.NullString{String: value, Valid: true}
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (actor, to_address, amount, reason, created_at)
+		VALUES ($1, $2, $3::numeric, $4, now())`, r.AuditTable)
+	_, err := tx.ExecContext(ctx, query, actor, toAddress, amount, reason)
+	return err
+}
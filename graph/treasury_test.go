@@ -0,0 +1,31 @@
+package graph
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTreasurySendOnly(t *testing.T) {
+	ctx := context.Background()
+	treasury := "0x0000000000000000000000000000000000000000"
+	aAddress := "0xa000000000000000000000000000000000000000"
+
+	// No DB: the treasury is rejected as recipient before any query
+	resolver := &Resolver{
+		WalletTable:      "wallets",
+		TreasuryAddress:  treasury,
+		TreasurySendOnly: true,
+		MintEnabled:      true,
+	}
+
+	_, err := resolver.Service().Transfer(ctx, aAddress, treasury, "1")
+	if err == nil || err.Error() != "transfers to treasury are disabled" {
+		t.Fatalf("Expected transfer to treasury to be rejected, got: %v", err)
+	}
+	if category := ErrorCategory(err); category != "rejected" {
+		t.Errorf("Expected rejected category, got %s", category)
+	}
+	if _, err := resolver.Mutation().Mint(ctx, treasury, "1", nil); err == nil || err.Error() != "minting to treasury is disabled" {
+		t.Errorf("Expected mint to treasury to be rejected, got: %v", err)
+	}
+}
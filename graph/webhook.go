@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+const (
+	webhookQueueSize = 100 // notifications waiting for delivery; further ones are dropped
+	webhookWorkers   = 2
+	webhookAttempts  = 3
+	webhookTimeout   = 5 * time.Second // per delivery attempt
+)
+
+// Delay before retry n is n * webhookRetryDelay
+var webhookRetryDelay = time.Second
+
+// JSON body POSTed to TransferWebhookURL after a committed transfer
+type webhookPayload struct {
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Asset         string    `json:"asset"`
+	Amount        string    `json:"amount"`
+	SenderBalance string    `json:"senderBalance"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Bounded queue of webhook notifications, delivered by background workers
+type webhookNotifier struct {
+	once   sync.Once
+	queue  chan webhookPayload
+	client *http.Client
+}
+
+// Queue notification of a committed transfer without blocking the caller
+// Does nothing when TransferWebhookURL is not set
+func (r *Resolver) notifyWebhook(result *model.TransferResult) {
+	if r.TransferWebhookURL == "" {
+		return
+	}
+
+	r.webhook.once.Do(func() {
+		r.webhook.queue = make(chan webhookPayload, webhookQueueSize)
+		r.webhook.client = &http.Client{Timeout: webhookTimeout}
+		for range webhookWorkers {
+			go r.runWebhookWorker(r.webhook.queue)
+		}
+	})
+
+	payload := webhookPayload{
+		From:          result.FromAddress,
+		To:            result.ToAddress,
+		Asset:         result.Asset,
+		Amount:        result.Amount,
+		SenderBalance: result.SenderBalance,
+		Timestamp:     result.Timestamp,
+	}
+	select {
+	case r.webhook.queue <- payload:
+	default:
+		r.logger().Error("transfer webhook queue full, notification dropped", "from", payload.From, "to", payload.To, "amount", payload.Amount)
+	}
+}
+
+func (r *Resolver) runWebhookWorker(queue <-chan webhookPayload) {
+	for payload := range queue {
+		var err error
+		for attempt := 1; attempt <= webhookAttempts; attempt++ {
+			if err = r.deliverWebhook(payload); err == nil {
+				break
+			}
+			if attempt < webhookAttempts {
+				time.Sleep(time.Duration(attempt) * webhookRetryDelay)
+			}
+		}
+		if err != nil {
+			r.logger().Error("transfer webhook delivery failed", "from", payload.From, "to", payload.To, "amount", payload.Amount, "attempts", webhookAttempts, "error", err)
+		}
+	}
+}
+
+// POST payload once; any non-2xx response is a failure
+func (r *Resolver) deliverWebhook(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.TransferWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.webhook.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"token_transfer/graph/model"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	webhookRetryDelay = time.Millisecond
+
+	var calls atomic.Int32
+	received := make(chan webhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// First attempt fails, so the notification is retried
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload webhookPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("Invalid webhook body: %v", err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	resolver := &Resolver{TransferWebhookURL: server.URL}
+	resolver.notifyWebhook(&model.TransferResult{
+		FromAddress:   "0xa000000000000000000000000000000000000000",
+		ToAddress:     "0xb000000000000000000000000000000000000000",
+		Amount:        "1.000000000000000000",
+		SenderBalance: "9.000000000000000000",
+		Timestamp:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+
+	select {
+	case payload := <-received:
+		if payload.From != "0xa000000000000000000000000000000000000000" || payload.Amount != "1.000000000000000000" || payload.SenderBalance != "9.000000000000000000" || !payload.Timestamp.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+			t.Errorf("Unexpected webhook payload: %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook was not delivered")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 delivery attempts, got %d", calls.Load())
+	}
+
+	// No URL: nothing is queued
+	idle := &Resolver{}
+	idle.notifyWebhook(&model.TransferResult{})
+	if idle.webhook.queue != nil {
+		t.Error("Expected no webhook queue without URL")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+
+	"token_transfer/graph"
+	"token_transfer/grpcserver"
+	"token_transfer/grpcserver/transferpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+func newGRPCServer(resolver *graph.Resolver, auth *apiKeyAuth, readOnly bool) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcClientIP, auth.unaryInterceptor))
+	transferpb.RegisterTokenTransferServer(server, grpcserver.New(resolver, readOnly))
+	return server
+}
+
+// Store the peer address in the context, like clientIP does for HTTP requests
+func grpcClientIP(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ctx = graph.WithClientIP(ctx, remoteHost(p.Addr.String()))
+	}
+	return handler(ctx, req)
+}
+
+// Wait for in-flight RPCs until ctx expires, then close remaining connections
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
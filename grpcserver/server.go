@@ -0,0 +1,92 @@
+// gRPC server for internal service-to-service calls
+// Stubs in transferpb are generated from proto/transfer.proto
+package grpcserver
+
+import (
+	"context"
+	"log"
+
+	"token_transfer/graph"
+	"token_transfer/graph/model"
+	"token_transfer/grpcserver/transferpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TokenTransfer service calling the same resolver logic as GraphQL and REST
+type Server struct {
+	transferpb.UnimplementedTokenTransferServer
+
+	resolver *graph.Resolver
+	readOnly bool
+}
+
+// Transfer RPC is rejected in read-only mode
+func New(resolver *graph.Resolver, readOnly bool) *Server {
+	return &Server{resolver: resolver, readOnly: readOnly}
+}
+
+// Check if RPC changes balances, so that it always requires an API key
+func IsWriteMethod(fullMethod string) bool {
+	return fullMethod == transferpb.TokenTransfer_Transfer_FullMethodName
+}
+
+func (s *Server) Transfer(ctx context.Context, req *transferpb.TransferRequest) (*transferpb.TransferResponse, error) {
+	if s.readOnly {
+		return nil, status.Error(codes.Unimplemented, "transfers are disabled in read-only mode")
+	}
+
+	options := graph.TransferOptions{ExpectedSenderBalance: req.ExpectedSenderBalance}
+	result, _, err := s.resolver.Service().TransferWithOptions(ctx, req.GetFromAddress(), req.GetToAddress(), req.GetAmount(), options)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &transferpb.TransferResponse{
+		FromAddress:      result.FromAddress,
+		ToAddress:        result.ToAddress,
+		SenderBalance:    result.SenderBalance,
+		RecipientBalance: result.RecipientBalance,
+		Amount:           result.Amount,
+		Timestamp:        timestamppb.New(result.Timestamp),
+	}, nil
+}
+
+func (s *Server) GetWallet(ctx context.Context, req *transferpb.GetWalletRequest) (*transferpb.Wallet, error) {
+	if !model.ValidAddress(req.GetAddress()) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address %q", req.GetAddress())
+	}
+
+	wallet, err := s.resolver.Query().Wallet(ctx, req.GetAddress(), nil)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &transferpb.Wallet{Address: wallet.Address, Balance: wallet.Balance, OwnerId: wallet.OwnerID, Frozen: wallet.Frozen}, nil
+}
+
+// Map resolver error to gRPC status code by its category, as the REST API does for HTTP status
+func statusError(err error) error {
+	code := codes.Internal
+	switch graph.ErrorCategory(err) {
+	case "invalid_address", "invalid_amount", "invalid_input":
+		code = codes.InvalidArgument
+	case "insufficient_balance", "insufficient_allowance", "wallet_frozen", "rejected":
+		code = codes.FailedPrecondition
+	case "conflict":
+		code = codes.Aborted
+	case "rate_limited":
+		code = codes.ResourceExhausted
+	case "timeout":
+		code = codes.DeadlineExceeded
+	case "not_found":
+		code = codes.NotFound
+	}
+
+	// DB error details stay in the server logs
+	if code == codes.Internal {
+		log.Printf("gRPC request failed: %v", err)
+		return status.Error(code, "internal error")
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"token_transfer/graph"
+	"token_transfer/grpcserver/transferpb"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	cases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{graph.ErrInsufficientBalance, codes.FailedPrecondition},
+		{graph.ErrInsufficientAllowance, codes.FailedPrecondition},
+		{fmt.Errorf("wrapped: %w", graph.ErrInvalidAddress), codes.InvalidArgument},
+		{graph.ErrInvalidAmount, codes.InvalidArgument},
+		{sql.ErrNoRows, codes.NotFound},
+		{&pq.Error{Code: "40P01", Message: "deadlock detected"}, codes.Internal},
+		{graph.ErrRateLimitExceeded, codes.ResourceExhausted},
+		{graph.ErrWriteConflict, codes.Aborted},
+		{graph.ErrWalletFrozen, codes.FailedPrecondition},
+		{graph.ErrTransferTimeout, codes.DeadlineExceeded},
+		{errors.New("transfers from treasury require treasuryTransfer"), codes.FailedPrecondition},
+	}
+
+	for _, c := range cases {
+		st := status.Convert(statusError(c.err))
+		if st.Code() != c.code {
+			t.Errorf("%v: expected code %s, got %s", c.err, c.code, st.Code())
+		}
+		// DB error details are not exposed
+		if c.code == codes.Internal && st.Message() != "internal error" {
+			t.Errorf("%v: expected generic message, got %s", c.err, st.Message())
+		}
+	}
+}
+
+func TestTransferRejectedBeforeDB(t *testing.T) {
+	// Resolver without DB: only requests rejected before reaching the DB are sent
+	resolver := &graph.Resolver{WalletTable: "wallets"}
+	req := &transferpb.TransferRequest{
+		FromAddress: "0xa000000000000000000000000000000000000000",
+		ToAddress:   "0xb000000000000000000000000000000000000000",
+		Amount:      "1e3",
+	}
+
+	_, err := New(resolver, false).Transfer(context.Background(), req)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for invalid amount, got: %v", err)
+	}
+
+	_, err = New(resolver, true).Transfer(context.Background(), req)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented in read-only mode, got: %v", err)
+	}
+}
+
+func TestGetWalletRejectsInvalidAddress(t *testing.T) {
+	// Resolver without DB: a malformed address is rejected before the lookup
+	resolver := &graph.Resolver{WalletTable: "wallets"}
+
+	_, err := New(resolver, false).GetWallet(context.Background(), &transferpb.GetWalletRequest{Address: "0xnotanaddress"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for invalid address, got: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: transfer.proto
+
+package transferpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TokenTransfer_Transfer_FullMethodName  = "/tokentransfer.v1.TokenTransfer/Transfer"
+	TokenTransfer_GetWallet_FullMethodName = "/tokentransfer.v1.TokenTransfer/GetWallet"
+)
+
+// TokenTransferClient is the client API for TokenTransfer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Internal service-to-service API with the same rules as the GraphQL transfer mutation
+type TokenTransferClient interface {
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	GetWallet(ctx context.Context, in *GetWalletRequest, opts ...grpc.CallOption) (*Wallet, error)
+}
+
+type tokenTransferClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTokenTransferClient(cc grpc.ClientConnInterface) TokenTransferClient {
+	return &tokenTransferClient{cc}
+}
+
+func (c *tokenTransferClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, TokenTransfer_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tokenTransferClient) GetWallet(ctx context.Context, in *GetWalletRequest, opts ...grpc.CallOption) (*Wallet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Wallet)
+	err := c.cc.Invoke(ctx, TokenTransfer_GetWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TokenTransferServer is the server API for TokenTransfer service.
+// All implementations must embed UnimplementedTokenTransferServer
+// for forward compatibility.
+//
+// Internal service-to-service API with the same rules as the GraphQL transfer mutation
+type TokenTransferServer interface {
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	GetWallet(context.Context, *GetWalletRequest) (*Wallet, error)
+	mustEmbedUnimplementedTokenTransferServer()
+}
+
+// UnimplementedTokenTransferServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTokenTransferServer struct{}
+
+func (UnimplementedTokenTransferServer) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedTokenTransferServer) GetWallet(context.Context, *GetWalletRequest) (*Wallet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWallet not implemented")
+}
+func (UnimplementedTokenTransferServer) mustEmbedUnimplementedTokenTransferServer() {}
+func (UnimplementedTokenTransferServer) testEmbeddedByValue()                       {}
+
+// UnsafeTokenTransferServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TokenTransferServer will
+// result in compilation errors.
+type UnsafeTokenTransferServer interface {
+	mustEmbedUnimplementedTokenTransferServer()
+}
+
+func RegisterTokenTransferServer(s grpc.ServiceRegistrar, srv TokenTransferServer) {
+	// If the following call pancis, it indicates UnimplementedTokenTransferServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TokenTransfer_ServiceDesc, srv)
+}
+
+func _TokenTransfer_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenTransferServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenTransfer_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenTransferServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TokenTransfer_GetWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenTransferServer).GetWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TokenTransfer_GetWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenTransferServer).GetWallet(ctx, req.(*GetWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TokenTransfer_ServiceDesc is the grpc.ServiceDesc for TokenTransfer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TokenTransfer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tokentransfer.v1.TokenTransfer",
+	HandlerType: (*TokenTransferServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Transfer",
+			Handler:    _TokenTransfer_Transfer_Handler,
+		},
+		{
+			MethodName: "GetWallet",
+			Handler:    _TokenTransfer_GetWallet_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transfer.proto",
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Time limit of a single health check
+const healthCheckTimeout = 2 * time.Second
+
+// Liveness: DB is reachable
+func healthHandler(db *sql.DB) http.Handler {
+	return healthCheck(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// Readiness: DB is reachable and wallet table can be queried
+func readyHandler(db *sql.DB, walletTable string) http.Handler {
+	query := fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", walletTable)
+	return healthCheck(func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return err
+		}
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	})
+}
+
+// Respond 200 {"status":"ok"} when check passes, 503 with the error otherwise
+func healthCheck(check func(ctx context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := check(ctx); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	})
+}
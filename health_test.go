@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected int
+		body     string
+	}{
+		{"healthy", nil, http.StatusOK, `{"status":"ok"}`},
+		{"unhealthy", errors.New("connection refused"), http.StatusServiceUnavailable, `{"error":"connection refused","status":"error"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := healthCheck(func(ctx context.Context) error { return c.err })
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rec.Code != c.expected {
+				t.Errorf("Expected status %d, got %d", c.expected, rec.Code)
+			}
+			if strings.TrimSpace(rec.Body.String()) != c.body {
+				t.Errorf("Expected body %s, got %s", c.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHealthHandlersClosedDB(t *testing.T) {
+	// Closed DB fails every call without a network round trip
+	db, err := sql.Open("postgres", "host=localhost")
+	if err != nil {
+		t.Fatalf("Failed to open DB: %v", err)
+	}
+	db.Close()
+
+	for path, handler := range map[string]http.Handler{
+		"/healthz": healthHandler(db),
+		"/readyz":  readyHandler(db, "wallets"),
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status 503, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Limits of a GraphQL operation, overridden by GRAPHQL_COMPLEXITY_LIMIT and GRAPHQL_DEPTH_LIMIT
+// A full page of transactions with every field costs about 1500, balances of 1000 addresses about 4000
+const (
+	defaultComplexityLimit = 5000
+	defaultDepthLimit      = 10
+)
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// Queries kept for automatic persisted queries, overridden by APQ_CACHE_SIZE; the least recently used is evicted
+const defaultAPQCacheSize = 1000
+
+// Reject operations nesting fields deeper than limit, before any resolver runs
+// Introspection is not counted, like in the complexity limit, so the playground keeps working
+type depthLimit struct {
+	limit int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = depthLimit{}
+
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d depthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Doc.Operations.ForName(opCtx.OperationName)
+	if depth := selectionDepth(op.SelectionSet); depth > d.limit {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.limit)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+	return nil
+}
+
+// Deepest level of nested fields; fragments add no level of their own
+// Fragment cycles are rejected by validation before this runs
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range selectionSet {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Name == "__schema" || s.Name == "__type" {
+				continue
+			}
+			depth = max(depth, 1+selectionDepth(s.SelectionSet))
+		case *ast.FragmentSpread:
+			depth = max(depth, selectionDepth(s.Definition.SelectionSet))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(s.SelectionSet))
+		}
+	}
+	return depth
+}
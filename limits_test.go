@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+func TestOperationLimits(t *testing.T) {
+	// Server without DB: operations within limits only get as far as resolver validation or introspection
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}, Complexity: graph.ListComplexity()}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(200))
+	srv.Use(depthLimit{limit: 3})
+
+	post := func(query string) string {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"too deep", `{ transactions(address: \"0xa000000000000000000000000000000000000000\") { edges { node { id } } } }`, errDepthLimit},
+		{"too deep through fragment", `query { wallets { ...page } } fragment page on WalletConnection { edges { node { address } } }`, errDepthLimit},
+		{"full page too complex", `{ wallets(first: 100) { edges { cursor } } }`, "COMPLEXITY_LIMIT_EXCEEDED"},
+		{"within limits", `{ wallets(first: 0) { edges { cursor } } }`, ""},
+		{"introspection not counted", `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`, ""},
+	}
+
+	for _, c := range cases {
+		body := post(c.query)
+		if c.code != "" && !strings.Contains(body, c.code) {
+			t.Errorf("%s: expected %s, got %s", c.name, c.code, body)
+		}
+		if c.code == "" && (strings.Contains(body, errDepthLimit) || strings.Contains(body, "COMPLEXITY_LIMIT_EXCEEDED")) {
+			t.Errorf("%s: expected operation within limits, got %s", c.name, body)
+		}
+	}
+}
+
+func TestAutomaticPersistedQuery(t *testing.T) {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](defaultAPQCacheSize)})
+
+	query := "{ __typename }"
+	sum := sha256.Sum256([]byte(query))
+	persisted := `"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hex.EncodeToString(sum[:]) + `"}}`
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Unknown hash: the client has to resend the full query
+	rec := post(`{` + persisted + `}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "PERSISTED_QUERY_NOT_FOUND") {
+		t.Fatalf("Expected PERSISTED_QUERY_NOT_FOUND, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Full query with its hash is executed and cached
+	rec = post(`{"query":"` + query + `",` + persisted + `}`)
+	if !strings.Contains(rec.Body.String(), `"__typename":"Query"`) {
+		t.Fatalf("Expected query result, got %s", rec.Body.String())
+	}
+
+	// Hash alone is enough afterwards
+	rec = post(`{` + persisted + `}`)
+	if !strings.Contains(rec.Body.String(), `"__typename":"Query"`) {
+		t.Errorf("Expected cached query result, got %s", rec.Body.String())
+	}
+}
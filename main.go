@@ -1,39 +1,56 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"token_transfer/graph"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Build DB connection string
-	connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-	)
-	fmt.Println(connStr)
+	// Read DB settings; fail early if any is missing
+	dbConf, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Subcommands print their result to stdout, so progress messages go to stderr
+	status := io.Writer(os.Stdout)
+	if len(os.Args) > 1 {
+		status = os.Stderr
+	}
+	fmt.Fprintf(status, "Connecting to DB %s at %s:%s as %s\n", dbConf.Name, dbConf.Host, dbConf.Port, dbConf.User)
 
 	// Open DB connection
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", dbConf.connString())
 	if err != nil {
 		log.Fatal("Error connecting to DB:", err)
 	}
 
+	dbConf.configurePool(db)
+
 	// Close connection when main() finishes
 	defer db.Close()
 
@@ -42,26 +59,380 @@ func main() {
 		log.Fatal("Ping failed:", err)
 	}
 
-	fmt.Println("Connected to DB.")
+	fmt.Fprintln(status, "Connected to DB.")
+
+	// Optional read replica for wallet and totalSupply queries; nil uses the primary
+	var readDB *sql.DB
+	if replicaConf, ok := dbConf.replicaConfig(); ok {
+		fmt.Fprintf(status, "Connecting to replica %s at %s:%s as %s\n", replicaConf.Name, replicaConf.Host, replicaConf.Port, replicaConf.User)
+		readDB, err = sql.Open("postgres", replicaConf.connString())
+		if err != nil {
+			log.Fatal("Error connecting to replica:", err)
+		}
+		replicaConf.configurePool(readDB)
+		defer readDB.Close()
+
+		if err := readDB.Ping(); err != nil {
+			log.Fatal("Replica ping failed:", err)
+		}
+		fmt.Fprintln(status, "Connected to replica.")
+	}
+
+	// Optional schema migrations, applied before anything queries the tables
+	if os.Getenv("RUN_MIGRATIONS") == "true" {
+		if err := runMigrations(db); err != nil {
+			log.Fatalf("Migrations failed: %v", err)
+		}
+	}
+
+	// Optional auto-creation of missing sender wallets
+	defaultSenderBalance := decimal.Zero
+	if value := os.Getenv("DEFAULT_SENDER_BALANCE"); value != "" {
+		defaultSenderBalance, err = decimal.NewFromString(value)
+		if err != nil || defaultSenderBalance.IsNegative() {
+			log.Fatalf("Invalid DEFAULT_SENDER_BALANCE %q", value)
+		}
+	}
+
+	// Min amount of a transfer that creates a new wallet
+	newWalletMinAmount := decimal.Zero
+	if value := os.Getenv("NEW_WALLET_MIN_AMOUNT"); value != "" {
+		newWalletMinAmount, err = decimal.NewFromString(value)
+		if err != nil || newWalletMinAmount.IsNegative() {
+			log.Fatalf("Invalid NEW_WALLET_MIN_AMOUNT %q", value)
+		}
+	}
+
+	// Min amount of a single transfer, e.g. to reject dust
+	minTransferAmount := decimal.Zero
+	if value := os.Getenv("MIN_TRANSFER_AMOUNT"); value != "" {
+		minTransferAmount, err = decimal.NewFromString(value)
+		if err != nil || minTransferAmount.IsNegative() {
+			log.Fatalf("Invalid MIN_TRANSFER_AMOUNT %q", value)
+		}
+	}
+
+	// Max amount of a single transfer
+	maxTransferAmount := decimal.Zero
+	if value := os.Getenv("MAX_TRANSFER_AMOUNT"); value != "" {
+		maxTransferAmount, err = decimal.NewFromString(value)
+		if err != nil || maxTransferAmount.IsNegative() {
+			log.Fatalf("Invalid MAX_TRANSFER_AMOUNT %q", value)
+		}
+	}
+
+	// Optional micro-batching of transfer commits
+	batchWindow, err := durationEnv("TRANSFER_BATCH_WINDOW", 0, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Guards against expensive or deeply nested GraphQL operations; 0 disables a limit
+	complexityLimit, maxDepth := defaultComplexityLimit, defaultDepthLimit
+	for _, v := range []struct {
+		key   string
+		value *int
+	}{
+		{"GRAPHQL_COMPLEXITY_LIMIT", &complexityLimit},
+		{"GRAPHQL_DEPTH_LIMIT", &maxDepth},
+	} {
+		if value := os.Getenv(v.key); value != "" {
+			*v.value, err = strconv.Atoi(value)
+			if err != nil || *v.value < 0 {
+				log.Fatalf("Invalid %s %q", v.key, value)
+			}
+		}
+	}
+
+	// Max size of a /query request body in bytes
+	maxBodySize := int64(defaultMaxRequestBodySize)
+	if value := os.Getenv("MAX_REQUEST_BODY_SIZE"); value != "" {
+		maxBodySize, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || maxBodySize <= 0 {
+			log.Fatalf("Invalid MAX_REQUEST_BODY_SIZE %q", value)
+		}
+	}
+
+	// Automatic persisted queries: clients may send a query hash instead of a query seen before
+	apqCacheSize := defaultAPQCacheSize
+	if value := os.Getenv("APQ_CACHE_SIZE"); value != "" {
+		apqCacheSize, err = strconv.Atoi(value)
+		if err != nil || apqCacheSize < 0 {
+			log.Fatalf("Invalid APQ_CACHE_SIZE %q", value)
+		}
+	}
+
+	var batchMaxSize int
+	if value := os.Getenv("TRANSFER_BATCH_MAX_SIZE"); value != "" {
+		batchMaxSize, err = strconv.Atoi(value)
+		if err != nil || batchMaxSize <= 0 {
+			log.Fatalf("Invalid TRANSFER_BATCH_MAX_SIZE %q", value)
+		}
+	}
+
+	// Attempts of a transfer with LOCK_STRATEGY=optimistic; 3 when not set
+	var optimisticAttempts int
+	if value := os.Getenv("OPTIMISTIC_ATTEMPTS"); value != "" {
+		optimisticAttempts, err = strconv.Atoi(value)
+		if err != nil || optimisticAttempts <= 0 {
+			log.Fatalf("Invalid OPTIMISTIC_ATTEMPTS %q", value)
+		}
+	}
+
+	// Retries of a transfer aborted with a serialization failure or deadlock; 0 disables them
+	transferRetries := 3
+	if value := os.Getenv("TRANSFER_RETRIES"); value != "" {
+		transferRetries, err = strconv.Atoi(value)
+		if err != nil || transferRetries < 0 {
+			log.Fatalf("Invalid TRANSFER_RETRIES %q", value)
+		}
+	}
+
+	// Isolation of transfer transactions; Postgres default (read committed) when not set
+	var isolationLevel sql.IsolationLevel
+	if value := os.Getenv("TRANSFER_ISOLATION"); value != "" {
+		level, ok := isolationLevels[value]
+		if !ok {
+			log.Fatalf("Invalid TRANSFER_ISOLATION %q", value)
+		}
+		isolationLevel = level
+	}
+
+	// Max transfers per sender per minute; 0 (default) disables the limit
+	var transferRateLimit int
+	if value := os.Getenv("TRANSFER_RATE_LIMIT"); value != "" {
+		transferRateLimit, err = strconv.Atoi(value)
+		if err != nil || transferRateLimit < 0 {
+			log.Fatalf("Invalid TRANSFER_RATE_LIMIT %q", value)
+		}
+	}
+
+	// Listen address from HOST (default: all interfaces) and PORT (default: 8080)
+	addr, err := listenAddress(os.Getenv("HOST"), getEnv("PORT", "8080"))
+	if err != nil {
+		log.Fatalf("Invalid listen address: %v", err)
+	}
+
+	// Optional gRPC server on GRPC_PORT, on the same HOST
+	var grpcAddr string
+	if value := os.Getenv("GRPC_PORT"); value != "" {
+		grpcAddr, err = listenAddress(os.Getenv("HOST"), value)
+		if err != nil {
+			log.Fatalf("Invalid GRPC_PORT: %v", err)
+		}
+	}
+
+	// Max duration of a transfer, so lock waits cannot pile up
+	transferTimeout, err := durationEnv("TRANSFER_TIMEOUT", 5*time.Second, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Time given to in-flight requests to finish on shutdown
+	shutdownTimeout, err := durationEnv("SHUTDOWN_TIMEOUT", 15*time.Second, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// HTTP server timeouts, so slow clients cannot hold connections open
+	httpTimeouts, err := loadHTTPTimeouts()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The write timeout covers the whole handler, so a transfer waiting on locks needs to finish before it
+	if httpTimeouts.Write > 0 && (transferTimeout == 0 || httpTimeouts.Write <= transferTimeout) {
+		log.Printf("HTTP_WRITE_TIMEOUT %s does not exceed TRANSFER_TIMEOUT %s; slow transfers may commit without a response", httpTimeouts.Write, transferTimeout)
+	}
+
+	// Audit log of every transfer as JSON lines on stderr; AUDIT_LOG=false discards it
+	auditLogger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	if os.Getenv("AUDIT_LOG") == "false" {
+		auditLogger = slog.New(slog.DiscardHandler)
+	}
 
 	// Start Graph server
 	resolver := &graph.Resolver{
-		DB:          db,
-		WalletTable: "wallets",
+		DB:     db,
+		ReadDB: readDB,
+		Tables: graph.TableConfig{
+			Wallets:    getEnv("WALLET_TABLE", "wallets"),
+			AddressCol: os.Getenv("WALLET_ADDRESS_COLUMN"),
+			BalanceCol: os.Getenv("WALLET_BALANCE_COLUMN"),
+		},
+		TransactionTable:       "transactions",
+		BaseAsset:              os.Getenv("BASE_ASSET"),
+		TreasuryAddress:        getEnv("TREASURY_ADDRESS", "0x0000000000000000000000000000000000000000"),
+		TreasuryProtected:      os.Getenv("TREASURY_PROTECTED") == "true",
+		TreasurySendOnly:       os.Getenv("TREASURY_SEND_ONLY") == "true",
+		AuditTable:             "treasury_audit",
+		AllowanceTable:         "allowances",
+		AllowOwnerRelink:       os.Getenv("ALLOW_OWNER_RELINK") == "true",
+		RepairBalancePrecision: os.Getenv("REPAIR_BALANCE_PRECISION") == "true",
+		LedgerImportEnabled:    os.Getenv("LEDGER_IMPORT_ENABLED") == "true",
+		LedgerExportEnabled:    os.Getenv("LEDGER_EXPORT_ENABLED") == "true",
+		MintEnabled:            os.Getenv("MINT_ENABLED") == "true",
+		AutoCreateSender:       os.Getenv("AUTO_CREATE_SENDER") == "true",
+		DefaultSenderBalance:   defaultSenderBalance,
+		NewWalletMinAmount:     newWalletMinAmount,
+		MinTransferAmount:      minTransferAmount,
+		MaxTransferAmount:      maxTransferAmount,
+		DecimalSeparator:       os.Getenv("DECIMAL_SEPARATOR"),
+		GroupSeparator:         os.Getenv("GROUP_SEPARATOR"),
+		Debug:                  os.Getenv("DEBUG") == "true",
+		TransferTimeout:        transferTimeout,
+		TransferRateLimit:      transferRateLimit,
+		TransferWebhookURL:     os.Getenv("TRANSFER_WEBHOOK_URL"),
+		LockStrategy:           graph.LockStrategy(os.Getenv("LOCK_STRATEGY")),
+		OptimisticAttempts:     optimisticAttempts,
+		TransferRetries:        transferRetries,
+		IsolationLevel:         isolationLevel,
+		BatchWindow:            batchWindow,
+		BatchMaxSize:           batchMaxSize,
+		AuditLogger:            auditLogger,
+		LogValidationFailures:  os.Getenv("LOG_VALIDATION_FAILURES") == "true",
+		LogLockOrder:           os.Getenv("LOG_LOCK_ORDER") == "true",
+		PoolerCompatible:       dbConf.PoolerCompatible,
+	}
+	if err := resolver.Validate(); err != nil {
+		log.Fatalf("Invalid resolver config: %v", err)
+	}
+
+	// One-off subcommand (init, transfer, balance): run it and exit instead of starting the server
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), resolver, os.Args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Prepare hot transfer queries once; transfers fall back to inline SQL if it fails
+	if err := resolver.Prepare(context.Background()); err != nil {
+		log.Printf("%v; using inline SQL", err)
+	}
+	defer resolver.Close()
+
+	// Distributed tracing, enabled by OTEL_EXPORTER_OTLP_ENDPOINT
+	shutdownTracing, err := setupTracing(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	// Read-only mode serves schema without mutations
+	config := graph.Config{Resolvers: resolver, Complexity: graph.ListComplexity()}
+	schema := graph.NewExecutableSchema(config)
+	readOnly := os.Getenv("READ_ONLY") == "true"
+	if readOnly {
+		schema = graph.NewReadOnlyExecutableSchema(config)
+		log.Println("Serving read-only schema: mutations are disabled")
 	}
 
-	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	// Production mode: no playground and introspection, CSRF token required on /query
+	production := os.Getenv("APP_ENV") == "production"
+	csrfToken := os.Getenv("CSRF_TOKEN")
+	if production && csrfToken == "" {
+		log.Fatal("CSRF_TOKEN is required when APP_ENV=production")
+	}
+
+	// API keys for mutations (and for queries unless PUBLIC_QUERIES=true)
+	// Admin keys for mint, burn, freezing, the treasury and ledger backups
+	auth := newAPIKeyAuth(os.Getenv("API_KEY"), os.Getenv("PUBLIC_QUERIES") == "true", production).
+		withAdminKeys(os.Getenv("ADMIN_API_KEYS"))
+	if !auth.enabled() {
+		log.Println("API_KEY is not set: requests are not authenticated")
+	} else if len(auth.adminKeys) == 0 {
+		log.Println("ADMIN_API_KEYS is not set: admin operations are rejected")
+	}
+
+	srv := handler.New(schema)
 
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(websocketTransport(os.Getenv("WS_ALLOWED_ORIGINS"), auth.websocketInit))
+	srv.AroundOperations(auth.operations)
+	srv.AroundFields(auth.fields)
+	srv.SetErrorPresenter(requestIDErrorPresenter)
+	if apqCacheSize > 0 {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](apqCacheSize)})
+	}
+	if complexityLimit > 0 {
+		srv.Use(extension.FixedComplexityLimit(complexityLimit))
+	}
+	if maxDepth > 0 {
+		srv.Use(depthLimit{limit: maxDepth})
+	}
 
-	srv.Use(extension.Introspection{})
+	if !production {
+		srv.Use(extension.Introspection{})
+		http.Handle("/", playground.Handler("GraphQL", "/query"))
+	}
+	http.Handle("/query", maxRequestBodySize(maxBodySize, csrfProtection(production, csrfToken, auth.middleware(srv))))
+	http.Handle("/api/", csrfProtection(production, csrfToken, restHandler(resolver, auth, readOnly)))
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/healthz", healthHandler(db))
+	http.Handle("/readyz", readyHandler(db, resolver.Tables.Wallets))
 
-	http.Handle("/", playground.Handler("GraphQL", "/query"))
-	http.Handle("/query", srv)
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("GraphQL server running at http://localhost:8080/")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// CORS for browser clients on other origins, enabled by ALLOWED_ORIGINS
+	httpServer := httpTimeouts.server(addr, requestID(clientIP(cors(os.Getenv("ALLOWED_ORIGINS"), http.DefaultServeMux))))
+	serverErr := make(chan error, 2)
+	go func() {
+		log.Printf("GraphQL server listening on %s", addr)
+		serverErr <- httpServer.ListenAndServe()
+	}()
 
+	var grpcServer *grpc.Server
+	if grpcAddr != "" {
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+		}
+		grpcServer = newGRPCServer(resolver, auth, readOnly)
+		go func() {
+			log.Printf("gRPC server listening on %s", grpcAddr)
+			serverErr <- grpcServer.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Stop accepting connections and wait for in-flight requests, so open
+	// DB transactions can commit before the connection pool is closed
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	if grpcServer != nil {
+		stopGRPCServer(shutdownCtx, grpcServer)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
+}
+
+// Return value of environment variable or fallback when it is not set
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// Build listen address; port must be a number in 1-65535
+func listenAddress(host, port string) (string, error) {
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber < 1 || portNumber > 65535 {
+		return "", fmt.Errorf("PORT must be a number between 1 and 65535, got %q", port)
+	}
+	return net.JoinHostPort(host, port), nil
 }
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newPost := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ __typename }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	cases := []struct {
+		name       string
+		production bool
+		token      string
+		expected   int
+	}{
+		{"production without token", true, "", http.StatusForbidden},
+		{"production with wrong token", true, "wrong", http.StatusForbidden},
+		{"production with token", true, "secret", http.StatusOK},
+		{"dev without token", false, "", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := newPost()
+		if c.token != "" {
+			req.Header.Set(csrfHeader, c.token)
+		}
+		rec := httptest.NewRecorder()
+
+		csrfProtection(c.production, "secret", ok).ServeHTTP(rec, req)
+
+		if rec.Code != c.expected {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.expected, rec.Code)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := func(method, origin string, preflight bool) *http.Request {
+		req := httptest.NewRequest(method, "/query", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		return req
+	}
+
+	cases := []struct {
+		name          string
+		allowed       string
+		req           *http.Request
+		status        int
+		allowedOrigin string
+	}{
+		{"disabled", "", request(http.MethodPost, "https://app.example", false), http.StatusOK, ""},
+		{"allowed origin", "https://app.example, https://admin.example", request(http.MethodPost, "https://admin.example", false), http.StatusOK, "https://admin.example"},
+		{"other origin", "https://app.example", request(http.MethodPost, "https://evil.example", false), http.StatusOK, ""},
+		{"same origin request", "https://app.example", request(http.MethodPost, "", false), http.StatusOK, ""},
+		{"preflight", "https://app.example", request(http.MethodOptions, "https://app.example", true), http.StatusNoContent, "https://app.example"},
+		{"preflight from other origin", "https://app.example", request(http.MethodOptions, "https://evil.example", true), http.StatusOK, ""},
+		{"wildcard", "*", request(http.MethodPost, "https://any.example", false), http.StatusOK, "*"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		cors(c.allowed, ok).ServeHTTP(rec, c.req)
+
+		if rec.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != c.allowedOrigin {
+			t.Errorf("%s: expected Access-Control-Allow-Origin %q, got %q", c.name, c.allowedOrigin, got)
+		}
+	}
+
+	// Preflight allows the headers the API needs
+	rec := httptest.NewRecorder()
+	cors("https://app.example", ok).ServeHTTP(rec, request(http.MethodOptions, "https://app.example", true))
+	for _, header := range []string{"Authorization", "Content-Type", csrfHeader} {
+		if !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), header) {
+			t.Errorf("Expected %s in Access-Control-Allow-Headers, got %q", header, rec.Header().Get("Access-Control-Allow-Headers"))
+		}
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") != corsAllowedMethods || rec.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("Unexpected preflight headers: %v", rec.Header())
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		w.Write(body)
+	})
+
+	cases := []struct {
+		name    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"under limit", strings.Repeat("a", 16), false, http.StatusOK},
+		{"at limit", strings.Repeat("a", 32), false, http.StatusOK},
+		{"declared length over limit", strings.Repeat("a", 33), false, http.StatusRequestEntityTooLarge},
+		{"unknown length over limit", strings.Repeat("a", 33), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(c.body))
+		if c.chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		maxRequestBodySize(32, echo).ServeHTTP(rec, req)
+
+		if rec.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rec.Code)
+			continue
+		}
+		if c.status == http.StatusOK {
+			if rec.Body.String() != c.body {
+				t.Errorf("%s: expected body to reach the handler unchanged, got %q", c.name, rec.Body.String())
+			}
+			continue
+		}
+
+		var resp struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decoding response: %v", c.name, err)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "request body too large: max 32 bytes" {
+			t.Errorf("%s: unexpected errors %+v", c.name, resp.Errors)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = graph.RequestIDFromContext(r.Context())
+	}))
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		if header != "" {
+			req.Header.Set(requestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Client ID is kept and echoed
+	rec := serve("req-42")
+	if seen != "req-42" || rec.Header().Get(requestIDHeader) != "req-42" {
+		t.Errorf("Expected req-42 in context and response, got %q and %q", seen, rec.Header().Get(requestIDHeader))
+	}
+
+	// Missing or unsafe IDs are replaced with a UUID
+	for _, header := range []string{"", "bad id\nforged log line", strings.Repeat("a", 129)} {
+		rec := serve(header)
+		if _, err := uuid.Parse(seen); err != nil || rec.Header().Get(requestIDHeader) != seen {
+			t.Errorf("%q: expected generated UUID in context and response, got %q and %q", header, seen, rec.Header().Get(requestIDHeader))
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	var seen string
+	h := clientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = graph.ClientIPFromContext(r.Context())
+	}))
+
+	// Port is dropped, forwarded headers are ignored
+	for remoteAddr, expected := range map[string]string{
+		"203.0.113.7:51234": "203.0.113.7",
+		"[2001:db8::1]:443": "2001:db8::1",
+		"@":                 "@",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != expected {
+			t.Errorf("%s: expected client IP %s, got %q", remoteAddr, expected, seen)
+		}
+	}
+}
+
+func TestRequestIDInGraphQLErrors(t *testing.T) {
+	// Server without DB: the wallet query fails on the asset before any query
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(requestIDErrorPresenter)
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ wallet(address: \"0xa000000000000000000000000000000000000000\", asset: \"bad\") { balance } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	requestID(srv).ServeHTTP(rec, req)
+
+	var response struct {
+		Errors []struct {
+			Message    string         `json:"message"`
+			Extensions map[string]any `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid response %s: %v", rec.Body.String(), err)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Extensions["request_id"] != "req-7" {
+		t.Errorf("Expected error with request_id req-7, got %s", rec.Body.String())
+	}
+}
+
+func TestListenAddress(t *testing.T) {
+	cases := []struct {
+		host, port string
+		expected   string
+		valid      bool
+	}{
+		{"", "8080", ":8080", true},
+		{"127.0.0.1", "3000", "127.0.0.1:3000", true},
+		{"::1", "65535", "[::1]:65535", true},
+		{"", "0", "", false},
+		{"", "65536", "", false},
+		{"", "http", "", false},
+		{"", "", "", false},
+	}
+
+	for _, c := range cases {
+		addr, err := listenAddress(c.host, c.port)
+		if c.valid && (err != nil || addr != c.expected) {
+			t.Errorf("listenAddress(%q, %q) = %q, %v; expected %q", c.host, c.port, addr, err, c.expected)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("listenAddress(%q, %q) expected error, got %q", c.host, c.port, addr)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"token_transfer/graph"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Header that must carry the CSRF token on state-changing requests
+const csrfHeader = "X-CSRF-Token"
+
+// In production, reject requests other than GET/OPTIONS without matching CSRF token
+// Browsers cannot set custom headers on cross-site form posts, so a forged POST is rejected
+// In dev mode requests pass through unchanged
+func csrfProtection(production bool, token string, next http.Handler) http.Handler {
+	if !production {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodOptions {
+			provided := r.Header.Get(csrfHeader)
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				http.Error(w, "missing or invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Set of origins in a comma-separated list, e.g. "https://app.example,https://admin.example"
+func parseOrigins(list string) map[string]bool {
+	origins := map[string]bool{}
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[origin] = true
+		}
+	}
+	return origins
+}
+
+// Methods and request headers browsers may use cross-origin; preflight results are cached for corsMaxAge
+const (
+	corsAllowedMethods = "GET, POST, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type, " + csrfHeader + ", " + requestIDHeader
+	corsMaxAge         = 10 * time.Minute
+)
+
+// Let browsers on allowedOrigins call the API from another origin
+// allowedOrigins is a comma-separated list; "*" allows every origin, empty disables CORS
+// Preflight requests from allowed origins are answered here; other requests pass through with CORS headers
+func cors(allowedOrigins string, next http.Handler) http.Handler {
+	origins := parseOrigins(allowedOrigins)
+	if len(origins) == 0 {
+		return next
+	}
+	anyOrigin := origins["*"]
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses differ by origin, so shared caches must not mix them up
+		header := w.Header()
+		header.Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" || (!anyOrigin && !origins[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		header.Set("Access-Control-Expose-Headers", requestIDHeader)
+
+		// Preflight: the browser asks before sending the actual request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Header carrying the correlation ID of a request, in both directions
+const requestIDHeader = "X-Request-ID"
+
+// Client-supplied IDs end up in logs, so only short IDs of safe characters are kept
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Take X-Request-ID from the request or generate a UUID, store it in the context and echo it in the response
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(graph.WithRequestID(r.Context(), id)))
+	})
+}
+
+// Store the client IP in the context, so rejected requests without an API key can be attributed
+// RemoteAddr is used rather than X-Forwarded-For, which any client can set
+func clientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(graph.WithClientIP(r.Context(), remoteHost(r.RemoteAddr))))
+	})
+}
+
+// Host of a "host:port" address; the address as is when it has no port
+func remoteHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// Add request_id to the extensions of every GraphQL error and log resolver errors with it,
+// so an error reported by a user can be tied to a server log line
+func requestIDErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	id := graph.RequestIDFromContext(ctx)
+	if id == "" {
+		return presented
+	}
+
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]any{}
+	}
+	presented.Extensions["request_id"] = id
+
+	// Errors without a path are request-level, e.g. parse errors, and are not logged
+	if len(presented.Path) > 0 {
+		slog.Warn("graphql error", "request_id", id, "path", presented.Path.String(), "error", presented.Message)
+	}
+	return presented
+}
+
+// Default max size of a /query request body, overridden by MAX_REQUEST_BODY_SIZE
+const defaultMaxRequestBodySize = 1 << 20
+
+// Reject request bodies larger than limit bytes with 413 and a GraphQL-style error
+// The body is read here, so a client streaming without Content-Length is cut off at the limit too
+func maxRequestBodySize(limit int64, next http.Handler) http.Handler {
+	tooLarge := func(w http.ResponseWriter) {
+		message := fmt.Sprintf("request body too large: max %d bytes", limit)
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{
+			"errors": []map[string]string{{"message": message}},
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			tooLarge(w)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				tooLarge(w)
+				return
+			}
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
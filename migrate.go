@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+)
+
+// Schema of the wallets, transactions, transactions_head, treasury_audit and allowances tables, applied in file name order
+// Migrations use IF NOT EXISTS, so a DB created from db/init.sql is adopted as is
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// "001_create_wallets.sql": version 001
+var migrationNameRegex = regexp.MustCompile(`^(\d{3})_[a-z0-9_]+\.sql$`)
+
+// Table recording applied migrations by version
+const migrationsTable = "schema_migrations"
+
+type migration struct {
+	version string
+	name    string
+	sql     string
+}
+
+// Embedded migrations sorted by version; fails on malformed names or duplicate versions
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := make([]migration, 0, len(names))
+	versions := make(map[string]bool)
+	for _, path := range names {
+		name := path[len("migrations/"):]
+		match := migrationNameRegex.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		if versions[match[1]] {
+			return nil, fmt.Errorf("duplicate migration version %s", match[1])
+		}
+		versions[match[1]] = true
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: match[1], name: name, sql: string(content)})
+	}
+	return migrations, nil
+}
+
+// Apply embedded migrations not yet recorded in schema_migrations
+// Each migration runs in its own transaction holding a lock on schema_migrations,
+// so instances starting together apply it once
+func runMigrations(db *sql.DB) error {
+	migrations, err := loadMigrations(migrationFS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	createTable := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, migrationsTable)
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create %s: %w", migrationsTable, err)
+	}
+
+	for _, m := range migrations {
+		applied, err := applyMigration(ctx, db, m)
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+		if applied {
+			log.Printf("Applied migration %s", m.name)
+		}
+	}
+	return nil
+}
+
+// Run migration unless already applied; reports whether it ran
+func applyMigration(ctx context.Context, db *sql.DB, m migration) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", migrationsTable)); err != nil {
+		return false, err
+	}
+
+	var applied bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE version = $1)", migrationsTable)
+	if err := tx.QueryRowContext(ctx, query, m.version).Scan(&applied); err != nil {
+		return false, err
+	}
+	if applied {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version) VALUES ($1)", migrationsTable), m.version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
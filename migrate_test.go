@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFS)
+	if err != nil {
+		t.Fatalf("Expected embedded migrations to load, got: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].name != "001_create_wallets.sql" {
+		t.Fatalf("Expected 001_create_wallets.sql first, got %+v", migrations)
+	}
+	for i, m := range migrations {
+		if i > 0 && m.version <= migrations[i-1].version {
+			t.Errorf("Expected migrations sorted by version, got %s after %s", m.version, migrations[i-1].version)
+		}
+		// Migrations must be safe on a DB created from db/init.sql
+		if !strings.Contains(m.sql, "IF NOT EXISTS") {
+			t.Errorf("Expected %s to use IF NOT EXISTS", m.name)
+		}
+	}
+
+	// Files are sorted by name, not by embedding order
+	fsys := fstest.MapFS{
+		"migrations/002_b.sql": {Data: []byte("SELECT 2")},
+		"migrations/001_a.sql": {Data: []byte("SELECT 1")},
+	}
+	migrations, err = loadMigrations(fsys)
+	if err != nil || len(migrations) != 2 || migrations[0].version != "001" || migrations[1].sql != "SELECT 2" {
+		t.Errorf("Expected 001 then 002, got %+v, %v", migrations, err)
+	}
+
+	invalid := []fstest.MapFS{
+		{"migrations/create_wallets.sql": {}},
+		{"migrations/1_create_wallets.sql": {}},
+		{"migrations/001_Create-Wallets.sql": {}},
+		{"migrations/001_a.sql": {}, "migrations/001_b.sql": {}},
+	}
+	for _, fsys := range invalid {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("Expected %v to be rejected", fsys)
+		}
+	}
+}
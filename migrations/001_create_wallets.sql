@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS wallets (
+    address TEXT PRIMARY KEY,
+    token_balance NUMERIC(28,18) NOT NULL CHECK (token_balance >= 0)
+);
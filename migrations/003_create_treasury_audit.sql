@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS treasury_audit (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT,
+    to_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
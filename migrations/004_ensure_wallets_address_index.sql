@@ -0,0 +1,17 @@
+-- Tables adopted by 001 may lack the primary key on address: every wallet lookup
+-- and ON CONFLICT (address) needs a unique index on it
+-- Skipped when one exists, so the primary key is not duplicated
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_index i
+        JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+        WHERE i.indrelid = 'wallets'::regclass
+          AND i.indisunique
+          AND i.indnkeyatts = 1
+          AND a.attname = 'address'
+    ) THEN
+        CREATE UNIQUE INDEX wallets_address_idx ON wallets (address);
+    END IF;
+END $$;
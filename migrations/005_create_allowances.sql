@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS allowances (
+    owner_address TEXT NOT NULL,
+    spender_address TEXT NOT NULL,
+    amount NUMERIC(28,18) NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (owner_address, spender_address)
+);
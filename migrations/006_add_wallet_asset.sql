@@ -0,0 +1,21 @@
+-- Balances are kept per asset; existing rows and transactions hold the default base asset
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS asset TEXT NOT NULL DEFAULT 'TOKEN';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS asset TEXT NOT NULL DEFAULT 'TOKEN';
+
+-- Wallets are keyed by (address, asset): a unique index on address alone, from 001 or 004,
+-- would allow only one asset per address. 004 also creates one on a DB adopted from db/init.sql
+DROP INDEX IF EXISTS wallets_address_idx;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_index i
+        WHERE i.indrelid = 'wallets'::regclass
+          AND i.indisprimary
+          AND i.indnkeyatts = 2
+    ) THEN
+        ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_pkey;
+        ALTER TABLE wallets ADD PRIMARY KEY (address, asset);
+    END IF;
+END $$;
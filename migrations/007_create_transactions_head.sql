@@ -0,0 +1,13 @@
+-- Hash of the last transaction in the log; every append locks this single row
+CREATE TABLE IF NOT EXISTS transactions_head (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    hash TEXT NOT NULL
+);
+
+-- Existing logs continue from their last transaction
+INSERT INTO transactions_head (id, hash)
+SELECT 1, COALESCE(
+    (SELECT hash FROM transactions ORDER BY id DESC LIMIT 1),
+    '0000000000000000000000000000000000000000000000000000000000000000'
+)
+ON CONFLICT (id) DO NOTHING;
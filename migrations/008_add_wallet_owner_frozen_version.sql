@@ -0,0 +1,7 @@
+-- Wallets created by 001 or adopted from the original db/init.sql lack the owner link,
+-- the freeze flag and the optimistic locking version
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS owner_id TEXT;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS wallets_owner_id_idx ON wallets (owner_id);
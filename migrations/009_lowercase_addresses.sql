@@ -0,0 +1,38 @@
+-- Addresses are stored lowercase; rows written by earlier versions may be checksum-cased,
+-- and the same wallet may then exist under several spellings. Each wallet is folded into
+-- one lowercase row: balances add up, it stays frozen if any spelling was, a linked owner
+-- is kept, and the version moves on so optimistic transfers in flight retry
+CREATE TEMP TABLE IF NOT EXISTS merged_wallets ON COMMIT DROP AS
+SELECT lower(address) AS address, asset, SUM(token_balance) AS token_balance,
+       MAX(owner_id) AS owner_id, bool_or(frozen) AS frozen, MAX(version) + 1 AS version
+FROM wallets
+WHERE lower(address) IN (SELECT lower(address) FROM wallets WHERE address <> lower(address))
+GROUP BY lower(address), asset;
+
+DELETE FROM wallets
+WHERE lower(address) IN (SELECT address FROM merged_wallets);
+
+INSERT INTO wallets (address, asset, token_balance, owner_id, frozen, version)
+SELECT address, asset, token_balance, owner_id, frozen, version
+FROM merged_wallets;
+
+-- Allowances between the same two wallets add up the same way
+CREATE TEMP TABLE IF NOT EXISTS merged_allowances ON COMMIT DROP AS
+SELECT lower(owner_address) AS owner_address, lower(spender_address) AS spender_address, SUM(amount) AS amount
+FROM allowances
+WHERE (lower(owner_address), lower(spender_address)) IN (
+    SELECT lower(owner_address), lower(spender_address)
+    FROM allowances
+    WHERE owner_address <> lower(owner_address) OR spender_address <> lower(spender_address)
+)
+GROUP BY lower(owner_address), lower(spender_address);
+
+DELETE FROM allowances
+WHERE (lower(owner_address), lower(spender_address)) IN (SELECT owner_address, spender_address FROM merged_allowances);
+
+INSERT INTO allowances (owner_address, spender_address, amount)
+SELECT owner_address, spender_address, amount
+FROM merged_allowances;
+
+-- The transaction log is left as recorded: receipt hashes cover the addresses as they
+-- were written, so rewriting them would break the hash chain
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"token_transfer/graph"
+	"token_transfer/graph/model"
+)
+
+// Max size of a REST request body
+const maxRESTBodySize = 1 << 20
+
+// JSON body of POST /api/transfer, with the same fields as the transfer mutation
+type restTransferRequest struct {
+	FromAddress           string  `json:"from_address"`
+	ToAddress             string  `json:"to_address"`
+	Amount                string  `json:"amount"`
+	ExpectedSenderBalance *string `json:"expected_sender_balance,omitempty"`
+}
+
+type restWallet struct {
+	Address string  `json:"address"`
+	Balance string  `json:"balance"`
+	OwnerID *string `json:"owner_id,omitempty"`
+	Frozen  bool    `json:"frozen"`
+}
+
+// Every error response is {"error": {"code": "...", "message": "..."}}
+type restError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// REST endpoints calling the same resolvers as GraphQL
+// POST /api/transfer is not registered in read-only mode
+func restHandler(resolver *graph.Resolver, auth *apiKeyAuth, readOnly bool) http.Handler {
+	mux := http.NewServeMux()
+
+	if !readOnly {
+		mux.Handle("POST /api/transfer", auth.requireKey(true, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request restTransferRequest
+			decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRESTBodySize))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&request); err != nil {
+				writeRESTError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body")
+				return
+			}
+
+			options := graph.TransferOptions{ExpectedSenderBalance: request.ExpectedSenderBalance}
+			result, _, err := resolver.Service().TransferWithOptions(r.Context(), request.FromAddress, request.ToAddress, request.Amount, options)
+			if err != nil {
+				writeResolverError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, result)
+		})))
+	}
+
+	mux.Handle("GET /api/wallet/{address}", auth.requireKey(false, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.PathValue("address")
+		if !model.ValidAddress(address) {
+			writeRESTError(w, http.StatusBadRequest, "invalid_address", fmt.Sprintf("invalid address %q", address))
+			return
+		}
+
+		wallet, err := resolver.Query().Wallet(r.Context(), address, nil)
+		if err != nil {
+			writeResolverError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, restWallet{Address: wallet.Address, Balance: wallet.Balance, OwnerID: wallet.OwnerID, Frozen: wallet.Frozen})
+	})))
+
+	return mux
+}
+
+// Map resolver error to HTTP status by its category
+func writeResolverError(w http.ResponseWriter, err error) {
+	category := graph.ErrorCategory(err)
+
+	status := http.StatusInternalServerError
+	switch category {
+	case "invalid_address", "invalid_amount", "invalid_input":
+		status = http.StatusBadRequest
+	case "insufficient_balance", "insufficient_allowance", "conflict":
+		status = http.StatusConflict
+	case "wallet_frozen":
+		status = http.StatusForbidden
+	case "rate_limited":
+		status = http.StatusTooManyRequests
+	case "timeout":
+		status = http.StatusServiceUnavailable
+	case "rejected":
+		status = http.StatusUnprocessableEntity
+	case "not_found":
+		status = http.StatusNotFound
+	}
+
+	// DB error details stay in the server logs
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Printf("REST request failed: %v", err)
+		message = "internal error"
+	}
+	writeRESTError(w, status, category, message)
+}
+
+func writeRESTError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]restError{"error": {Code: code, Message: message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"token_transfer/graph"
+
+	"github.com/lib/pq"
+)
+
+func TestWriteResolverError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{graph.ErrInsufficientBalance, http.StatusConflict, "insufficient_balance"},
+		{graph.ErrInsufficientAllowance, http.StatusConflict, "insufficient_allowance"},
+		{fmt.Errorf("wrapped: %w", graph.ErrInvalidAddress), http.StatusBadRequest, "invalid_address"},
+		{graph.ErrInvalidAmount, http.StatusBadRequest, "invalid_amount"},
+		{sql.ErrNoRows, http.StatusNotFound, "not_found"},
+		{&pq.Error{Code: "40P01", Message: "deadlock detected"}, http.StatusInternalServerError, "db_error"},
+		{graph.ErrRateLimitExceeded, http.StatusTooManyRequests, "rate_limited"},
+		{graph.ErrWriteConflict, http.StatusConflict, "conflict"},
+		{fmt.Errorf("%w: 0xa000000000000000000000000000000000000000", graph.ErrWalletFrozen), http.StatusForbidden, "wallet_frozen"},
+		{errors.New("transfers from treasury require treasuryTransfer"), http.StatusUnprocessableEntity, "rejected"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		writeResolverError(rec, c.err)
+
+		if rec.Code != c.status {
+			t.Errorf("%v: expected status %d, got %d", c.err, c.status, rec.Code)
+		}
+
+		var body map[string]restError
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%v: invalid error envelope: %v", c.err, err)
+		}
+		if body["error"].Code != c.code {
+			t.Errorf("%v: expected code %s, got %s", c.err, c.code, body["error"].Code)
+		}
+		// DB error details are not exposed
+		if c.status == http.StatusInternalServerError && body["error"].Message != "internal error" {
+			t.Errorf("%v: expected generic message, got %s", c.err, body["error"].Message)
+		}
+	}
+}
+
+func TestRESTTransferRequest(t *testing.T) {
+	// Resolver without DB: only requests rejected before reaching the DB are sent
+	resolver := &graph.Resolver{WalletTable: "wallets"}
+
+	post := func(h http.Handler, body, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/transfer", strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	validBody := `{"from_address": "0xa000000000000000000000000000000000000000", "to_address": "0xb000000000000000000000000000000000000000", "amount": "1e3"}`
+
+	cases := []struct {
+		name          string
+		handler       http.Handler
+		body          string
+		authorization string
+		status        int
+	}{
+		{"invalid JSON", restHandler(resolver, newAPIKeyAuth("", false, false), false), `{"from":`, "", http.StatusBadRequest},
+		{"unknown field", restHandler(resolver, newAPIKeyAuth("", false, false), false), `{"from": "0xa"}`, "", http.StatusBadRequest},
+		{"invalid amount", restHandler(resolver, newAPIKeyAuth("", false, false), false), validBody, "", http.StatusBadRequest},
+		{"missing key", restHandler(resolver, newAPIKeyAuth("secret", true, false), false), validBody, "", http.StatusUnauthorized},
+		{"valid key", restHandler(resolver, newAPIKeyAuth("secret", true, false), false), validBody, "Bearer secret", http.StatusBadRequest},
+		{"read-only", restHandler(resolver, newAPIKeyAuth("", false, false), true), validBody, "", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		rec := post(c.handler, c.body, c.authorization)
+		if rec.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d: %s", c.name, c.status, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestRESTWalletRequest(t *testing.T) {
+	// Resolver without DB: a malformed address is rejected before the lookup
+	handler := restHandler(&graph.Resolver{WalletTable: "wallets"}, newAPIKeyAuth("", false, false), false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/wallet/0xnotanaddress", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+	var body map[string]restError
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body["error"].Code != "invalid_address" {
+		t.Errorf("Expected invalid_address error, got %+v, %v", body, err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+)
+
+// Interval of keep-alive messages, so idle subscriptions survive proxies
+const websocketKeepAlive = 10 * time.Second
+
+// Websocket transport delivering GraphQL subscriptions
+// allowedOrigins is a comma-separated list of origins; every origin is allowed when it is empty
+// initFunc can authenticate connections with the connection_init payload
+func websocketTransport(allowedOrigins string, initFunc transport.WebsocketInitFunc) transport.Websocket {
+	return transport.Websocket{
+		KeepAlivePingInterval: websocketKeepAlive,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: originChecker(allowedOrigins),
+		},
+		InitFunc: initFunc,
+	}
+}
+
+// Return origin check for the websocket upgrade
+// Browsers always send Origin, so an allow-list stops other sites from opening subscriptions
+func originChecker(allowedOrigins string) func(r *http.Request) bool {
+	allowed := parseOrigins(allowedOrigins)
+	return func(r *http.Request) bool {
+		if len(allowed) == 0 {
+			return true
+		}
+		return allowed[r.Header.Get("Origin")]
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOriginChecker(t *testing.T) {
+	cases := []struct {
+		name           string
+		allowedOrigins string
+		origin         string
+		expected       bool
+	}{
+		{"no allow-list", "", "https://evil.example", true},
+		{"allowed origin", "https://app.example, https://admin.example", "https://admin.example", true},
+		{"other origin", "https://app.example,https://admin.example", "https://evil.example", false},
+		{"missing origin", "https://app.example", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/query", nil)
+			if c.origin != "" {
+				request.Header.Set("Origin", c.origin)
+			}
+
+			if got := originChecker(c.allowedOrigins)(request); got != c.expected {
+				t.Errorf("Expected %v for origin %q, got %v", c.expected, c.origin, got)
+			}
+		})
+	}
+}